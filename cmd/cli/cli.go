@@ -10,6 +10,6 @@ import (
 func main() {
 	if err := cli.RootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -81,19 +81,12 @@ var addressGenCmd = &cobra.Command{
 				}
 			}
 
-			if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) {
-				addresses, err := skyWallet.DecodeResponseSkycoinAddress(msg)
-				if err != nil {
-					return err
-				}
-				fmt.Println(addresses)
-			} else {
-				failMsg, err := skyWallet.DecodeFailMsg(msg)
-				if err != nil {
-					return err
-				}
-				return fmt.Errorf("failed with code: %s", failMsg)
+			// a Failure is returned as a device error, any other message as a protocol error
+			addresses, err := skyWallet.DecodeResponseSkycoinAddress(msg)
+			if err != nil {
+				return err
 			}
+			fmt.Println(addresses)
 			return nil
 		},
 	}
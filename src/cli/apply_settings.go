@@ -61,7 +61,7 @@ var applySettingsCmd = &cobra.Command{
 				}
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
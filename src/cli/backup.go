@@ -62,7 +62,7 @@ var backupCmd = &cobra.Command{
 				}
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
@@ -36,7 +36,7 @@ var cancelCmd = &cobra.Command{
 				return err
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
@@ -13,6 +13,8 @@ func init() {
 	checkMessageSignatureCmd.Flags().StringVar(&message, "message", "", "The message that the signature claims to be signing.")
 	checkMessageSignatureCmd.Flags().StringVar(&signature, "signature", "", "Signature of the message.")
 	checkMessageSignatureCmd.Flags().StringVar(&address, "address", "", "Address to verify against the signature.")
+	checkMessageSignatureCmd.Flags().StringVar(&coinTypeStr, "coinTypeStr", "SKY", "Coin type of the address, BTC signatures are in the Bitcoin Signed Message format.")
+	checkMessageSignatureCmd.Flags().BoolVar(&onDevice, "on-device", false, "Ask the device to check the signature instead of checking it locally.")
 	checkMessageSignatureCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
 }
 
@@ -20,6 +22,29 @@ var checkMessageSignatureCmd = &cobra.Command{
 		Use:   "checkMessageSignature",
 		Short: "Check a message signature matches the given address.",
 		RunE: func(_ *cobra.Command, _ []string) error {
+			coinType, err := skyWallet.CoinTypeFromString(coinTypeStr)
+			if err != nil {
+				return err
+			}
+
+			if !onDevice {
+				switch coinType {
+				case skyWallet.BitcoinCoinType:
+					err = skyWallet.VerifyBitcoinMessageSignature(message, signature, address)
+				default:
+					err = skyWallet.VerifyMessageSignature(message, signature, address)
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Println(address)
+				return nil
+			}
+
+			if coinType != skyWallet.SkycoinCoinType {
+				return fmt.Errorf("the device can only check %s signatures", skyWallet.SkycoinCoinType)
+			}
+
 			device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
 			if device == nil {
 				return fmt.Errorf("failed to create device")
@@ -38,7 +63,7 @@ var checkMessageSignatureCmd = &cobra.Command{
 				return err
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
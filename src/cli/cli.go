@@ -22,21 +22,33 @@ func init() {
 	RootCmd.AddCommand(
 		applySettingsCmd,
 		setMnemonicCmd,
+		loadDeviceCmd,
 		featuresCmd,
 		generateMnemonicCmd,
+		resetCmd,
 		addressGenCmd,
+		verifyAddressCmd,
+		discoverAddressesCmd,
+		exportWatchOnlyCmd,
 		firmwareUpdate,
 		signMessageCmd,
 		checkMessageSignatureCmd,
+		signFileCmd,
+		verifyFileCmd,
 		setPinCode,
 		removePinCode,
 		wipeCmd,
 		backupCmd,
 		recoveryCmd,
+		verifyBackupCmd,
 		cancelCmd,
 		transactionSignCmd,
 		getRawEntropyCmd,
 		getMixedEntropyCmd,
 		getUsbDetails,
+		provisionCmd,
+		rawCmd,
+		pingCmd,
+		doctorCmd,
 	)
 }
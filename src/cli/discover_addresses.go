@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	discoverAddressesCmd.Flags().IntVar(&gapLimit, "gapLimit", skyWallet.DefaultGapLimit, "Number of consecutive unused addresses that ends the scan.")
+	discoverAddressesCmd.Flags().IntVar(&batchSize, "batchSize", skyWallet.DefaultDiscoveryBatchSize, "Number of addresses asked to the device at once.")
+	discoverAddressesCmd.Flags().StringVar(&nodeURL, "node", "", "Skycoin node used to look up balances, i.e. http://127.0.0.1:6420. Without it every address is considered unused.")
+	discoverAddressesCmd.Flags().StringVar(&cacheFile, "cacheFile", defaultAddressBookPath(), "File caching the addresses derived by the device.")
+	discoverAddressesCmd.Flags().StringVar(&coinTypeStr, "coinTypeStr", "SKY", "Coin type to use on hardware-wallet.")
+	discoverAddressesCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var discoverAddressesCmd = &cobra.Command{
+	Use:   "discoverAddresses",
+	Short: "Scan the device addresses until a gap of unused addresses, caching them locally.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		coinType, err := skyWallet.CoinTypeFromString(coinTypeStr)
+		if err != nil {
+			return err
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the scan asks the device many times, keep it connected and initialized once
+		session, err := device.OpenSession()
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		info, err := device.DeviceInfo()
+		if err != nil {
+			return err
+		}
+		derive := device.AddressDeriver(coinType, readPinMatrix, readPassphrase)
+		key := skyWallet.WalletKey{DeviceID: info.DeviceID, CoinType: coinType}
+		if info.PassphraseProtection {
+			// each passphrase opens a different wallet, its first address tells them apart
+			first, err := derive(0, 1)
+			if err != nil {
+				return err
+			}
+			key.PassphraseState = first[0]
+		}
+
+		book, err := skyWallet.LoadAddressBook(cacheFile)
+		if err != nil {
+			return err
+		}
+
+		var lookup skyWallet.BalanceLookup = skyWallet.StaticBalances{}
+		if nodeURL != "" {
+			lookup = skyWallet.NodeBalanceLookup{URL: nodeURL}
+		}
+
+		discovered, err := book.Discover(key, derive, lookup, skyWallet.DiscoveryOptions{
+			GapLimit:  gapLimit,
+			BatchSize: batchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if err := book.Save(); err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(discovered, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+// defaultAddressBookPath returns the address cache file in the user home directory
+func defaultAddressBookPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "addresses.json"
+	}
+	return filepath.Join(home, ".skycoin-hw-cli", "addresses.json")
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the host can talk to the device over usb and print how to fix what prevents it.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		checks := skyWallet.Diagnose()
+		for _, c := range checks {
+			fmt.Printf("%-10s %s: %s\n", "["+c.Status.String()+"]", c.Name, c.Detail)
+			if c.Status == skyWallet.CheckOK {
+				continue
+			}
+			for _, step := range c.Remediation {
+				fmt.Printf("%-10s   - %s\n", "", step)
+			}
+		}
+
+		if skyWallet.Failed(checks) {
+			return errors.New("the device can not be used, fix the failed checks")
+		}
+		return nil
+	},
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"errors"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+// Exit codes of the commands, so scripts can tell why a command failed
+const (
+	// ExitError is returned for any other error, i.e. invalid arguments
+	ExitError = 1
+	// ExitNotFound is returned if no device is connected
+	ExitNotFound = 2
+	// ExitPermissionDenied is returned if the user is not allowed to open the device
+	ExitPermissionDenied = 3
+	// ExitTransport is returned if the device was disconnected or could not be reached
+	ExitTransport = 4
+	// ExitProtocol is returned if the device answered something unexpected or malformed
+	ExitProtocol = 5
+	// ExitDeviceFailure is returned if the device answered with a failure
+	ExitDeviceFailure = 6
+	// ExitCancelled is returned if the action was cancelled on the device
+	ExitCancelled = 7
+	// ExitWalletSwap is returned if the passphrase opened another hidden wallet
+	// than the one used so far, the action is cancelled on the device
+	ExitWalletSwap = 8
+)
+
+// ExitCode returns the exit code of a command that failed with err
+func ExitCode(err error) int {
+	var transportErr skyWallet.TransportError
+	var protocolErr skyWallet.ProtocolError
+	var deviceErr skyWallet.DeviceError
+	var walletSwapErr skyWallet.WalletSwapError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &transportErr):
+		switch {
+		case transportErr.NotFound():
+			return ExitNotFound
+		case transportErr.PermissionDenied():
+			return ExitPermissionDenied
+		default:
+			return ExitTransport
+		}
+	case errors.As(err, &protocolErr):
+		return ExitProtocol
+	case errors.As(err, &walletSwapErr):
+		return ExitWalletSwap
+	case errors.As(err, &deviceErr):
+		if deviceErr.Cancelled() {
+			return ExitCancelled
+		}
+		return ExitDeviceFailure
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestExitCode(t *testing.T) {
+	features, err := skyWallet.Encode(&messages.Features{})
+	require.NoError(t, err)
+	_, unexpected := skyWallet.DecodeSuccess(features)
+
+	tt := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{
+			name: "success",
+			code: 0,
+		},
+		{
+			name: "not found",
+			err:  skyWallet.TransportError{Err: skyWallet.ErrNoDeviceConnected},
+			code: ExitNotFound,
+		},
+		{
+			name: "permission denied",
+			err:  skyWallet.TransportError{Err: usb.ErrPermissionDenied},
+			code: ExitPermissionDenied,
+		},
+		{
+			name: "disconnected",
+			err:  skyWallet.TransportError{Err: usb.ErrDisconnect},
+			code: ExitTransport,
+		},
+		{
+			name: "malformed message",
+			err:  skyWallet.ProtocolError{Err: wire.ErrMalformedMessage},
+			code: ExitProtocol,
+		},
+		{
+			name: "unexpected message",
+			err:  unexpected,
+			code: ExitProtocol,
+		},
+		{
+			name: "device failure",
+			err:  skyWallet.DeviceError{Code: messages.FailureType_Failure_PinInvalid, Message: "PIN invalid"},
+			code: ExitDeviceFailure,
+		},
+		{
+			name: "action cancelled",
+			err:  skyWallet.DeviceError{Code: messages.FailureType_Failure_ActionCancelled, Message: "Action cancelled by user"},
+			code: ExitCancelled,
+		},
+		{
+			name: "pin cancelled",
+			err:  skyWallet.DeviceError{Code: messages.FailureType_Failure_PinCancelled, Message: "PIN cancelled"},
+			code: ExitCancelled,
+		},
+		{
+			name: "wallet swap",
+			err:  skyWallet.WalletSwapError{DeviceID: "ABCD"},
+			code: ExitWalletSwap,
+		},
+		{
+			name: "wrapped transport error",
+			err:  fmt.Errorf("provisioning step label failed: %w", skyWallet.TransportError{Err: usb.ErrDisconnect}),
+			code: ExitTransport,
+		},
+		{
+			name: "wrapped device failure",
+			err:  fmt.Errorf("provisioning step pin failed: %w", skyWallet.DeviceError{Code: messages.FailureType_Failure_ActionCancelled}),
+			code: ExitCancelled,
+		},
+		{
+			name: "plain error",
+			err:  errors.New("invalid coin type"),
+			code: ExitError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.code, ExitCode(tc.err))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	exportWatchOnlyCmd.Flags().IntVar(&addressN, "addressN", 10, "Number of addresses to export.")
+	exportWatchOnlyCmd.Flags().StringVar(&walletFile, "walletFile", "", "Path of the .wlt file to write, it must not exist.")
+	exportWatchOnlyCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var exportWatchOnlyCmd = &cobra.Command{
+	Use:   "exportWatchOnly",
+	Short: "Write the device addresses to a Skycoin wallet file without any secret, to monitor balances.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if walletFile == "" {
+			return fmt.Errorf("walletFile is required")
+		}
+		if _, err := os.Stat(walletFile); err == nil {
+			return fmt.Errorf("%s already exists", walletFile)
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		// every address is signed separately, keep the device connected and initialized once
+		session, err := device.OpenSession()
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		wallet, err := device.ExportWatchOnly(filepath.Base(walletFile), uint32(addressN), readPinMatrix, readPassphrase)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(wallet, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(walletFile, b, 0600); err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported %d addresses to %s\n", len(wallet.Entries), walletFile)
+		return nil
+	},
+}
@@ -52,21 +52,34 @@ var featuresCmd = &cobra.Command{
 				if err = enc.Encode(features); err != nil {
 					return err
 				}
-				ff := skyWallet.NewFirmwareFeatures(uint64(*features.FirmwareFeatures))
-				if err := ff.Unmarshal(); err != nil {
+				info, err := skyWallet.NewDeviceInfo(features)
+				if err != nil {
 					return err
 				}
-				log.Printf("\n\nFirmware features:\n%s", ff)
-			case uint16(messages.MessageType_MessageType_Failure), uint16(messages.MessageType_MessageType_Success):
-				msgData, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+				log.Printf("\n\nDevice info:\n%s", info)
+				log.Printf("\n\nFirmware features:\n%s", info.FirmwareFeatures.Report())
+			case uint16(messages.MessageType_MessageType_Success):
+				msgData, err := skyWallet.DecodeSuccessMsg(msg)
 				if err != nil {
 					return err
 				}
 
 				fmt.Println(msgData)
 			default:
-				return fmt.Errorf("received unexpected message type: %s", messages.MessageType(msg.Kind))
+				// a Failure is returned as a device error, any other message as a protocol error
+				_, err := skyWallet.DecodeFeaturesMsg(msg)
+				return err
 			}
 			return nil
 		},
 }
+
+// requireFirmware asks the device its features and fails if require reports
+// the firmware lacks the feature the command needs
+func requireFirmware(device *skyWallet.Device, require func(skyWallet.DeviceInfo) error) error {
+	info, err := device.DeviceInfo()
+	if err != nil {
+		return err
+	}
+	return require(*info)
+}
@@ -45,7 +45,7 @@ var generateMnemonicCmd = &cobra.Command{
 				}
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
@@ -32,6 +32,10 @@ var getMixedEntropyCmd = &cobra.Command{
 				}
 			}
 
+			if err := requireFirmware(device, skyWallet.DeviceInfo.RequireEntropy); err != nil {
+				return err
+			}
+
 			entropy, err := skyWallet.MessageDeviceGetMixedEntropy(uint32(entropyBytes))
 			if err != nil {
 				return err
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/skycoin/hardware-wallet-go/src/skywallet"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
 
 	messages "github.com/skycoin/hardware-wallet-protob/go"
 	"github.com/skycoin/skycoin/src/util/logging"
@@ -358,6 +359,12 @@ func TestCheckMessageSignature(t *testing.T) {
 			args: []string{"checkMessageSignature",
 				"--address", "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "--message", "Hello World", "--signature", "f026001ee2d4e6bf4dfdbbdd33b6622bae24c8f6232fc19e5dd0013f2b68f0f14606d448897df20348c8b63dcad6923e9fecb2fe0969b183dc31eb4796e001d101"},
 		},
+		{
+			name:    "checkMessageSignature --on-device",
+			address: "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw",
+			args: []string{"checkMessageSignature", "--on-device",
+				"--address", "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "--message", "Hello World", "--signature", "f026001ee2d4e6bf4dfdbbdd33b6622bae24c8f6232fc19e5dd0013f2b68f0f14606d448897df20348c8b63dcad6923e9fecb2fe0969b183dc31eb4796e001d101"},
+		},
 	}
 
 	for _, tc := range tt {
@@ -527,15 +534,18 @@ func TestRecovery(t *testing.T) {
 		scanner.Split(bufio.ScanWords)
 		for scanner.Scan() {
 			m := scanner.Text()
-			if m == "Word:" {
+			if m == "Word:" && !stdInDone {
 				time.Sleep(1 * time.Second)
 				_, err := stdInPipe.Write([]byte("foobar\n"))
 				require.NoError(t, err)
 
 				stdInDone = true
 			} else if stdInDone {
-				if m == "Wrong" || m == "Word" {
+				// Words not in the BIP-39 wordlist are rejected by the cli
+				// without being sent to the device
+				if m == "Unknown" {
 					fail = true
+					require.NoError(t, stdInPipe.Close())
 					break
 				}
 			}
@@ -550,9 +560,13 @@ func TestRecovery(t *testing.T) {
 		}
 	}()
 
+	// stdin is closed after the rejected word so the command exits with an error
 	err = cmd.Wait()
-	require.NoError(t, err)
+	require.Error(t, err)
 	require.True(t, fail)
+
+	_, err = device.Cancel()
+	require.NoError(t, err)
 }
 
 func TestSetMnemonic(t *testing.T) {
@@ -600,7 +614,7 @@ func TestSetMnemonic(t *testing.T) {
 			name: "setMnemonic invalid mnemonic length",
 			args: []string{"setMnemonic", "--mnemonic",
 				"dress fee animal silly multiply demand casino gold pipe matrix latin badge umbrella orbit safe cover glove one dash chicken play obey"},
-			expectedOutput: "Mnemonic with wrong checksum provided",
+			expectedOutput: bip39.ErrChecksumMismatch.Error(),
 		},
 	}
 
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	loadDeviceCmd.Flags().StringVar(&mnemonic, "mnemonic", "", "Mnemonic the device is loaded with.")
+	loadDeviceCmd.Flags().StringVar(&hdNode, "node", "", "HD node the device is loaded with instead of a mnemonic, as JSON with the protobuf field names and base64 keys.")
+	loadDeviceCmd.Flags().StringVar(&pinCode, "pin", "", "PIN code in plain digits, no PIN is set if empty.")
+	loadDeviceCmd.Flags().BoolVar(&usePassphrase, "usePassphrase", false, "Configure a passphrase")
+	loadDeviceCmd.Flags().StringVar(&language, "language", "", "Device language.")
+	loadDeviceCmd.Flags().StringVar(&label, "label", "", "Label to identify the device.")
+	loadDeviceCmd.Flags().BoolVar(&skipChecksum, "skipChecksum", false, "Load the mnemonic without checking it is a valid BIP-39 mnemonic.")
+	loadDeviceCmd.Flags().StringVar(&deviceType, "deviceType", "EMULATOR", "Device type to send instructions to, only the emulator (EMULATOR) accepts it.")
+}
+
+var loadDeviceCmd = &cobra.Command{
+	Use:   "loadDevice",
+	Short: "Load a seed, PIN code and settings in a single message, for test fixtures on the emulator.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := skyWallet.LoadDeviceOptions{
+			Mnemonic:             mnemonic,
+			Pin:                  pinCode,
+			PassphraseProtection: usePassphrase,
+			Language:             language,
+			Label:                label,
+			SkipChecksum:         skipChecksum,
+		}
+		if hdNode != "" {
+			opts.Node = &messages.HDNodeType{}
+			if err := json.Unmarshal([]byte(hdNode), opts.Node); err != nil {
+				return fmt.Errorf("invalid node: %v", err)
+			}
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		msg, err := device.LoadDevice(opts)
+		if err != nil {
+			return err
+		}
+
+		for msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
+			msg, err = device.ButtonAck()
+			if err != nil {
+				return err
+			}
+		}
+
+		responseMsg, err := skyWallet.DecodeSuccess(msg)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(responseMsg)
+		return nil
+	},
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// LogLevelEnv sets the default of the --log-level flag
+	LogLevelEnv = "SKYWALLET_LOG_LEVEL"
+	// LogFormatEnv sets the default of the --log-format flag
+	LogFormatEnv = "SKYWALLET_LOG_FORMAT"
+)
+
+var (
+	logLevel  string
+	logFormat string
+)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&logLevel, "log-level", envOr(LogLevelEnv, "info"),
+		fmt.Sprintf("Log level: debug, info, warn, error, fatal or panic, debug logs every protocol message. [$%s]", LogLevelEnv))
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", envOr(LogFormatEnv, "text"),
+		fmt.Sprintf("Log format: text or json. [$%s]", LogFormatEnv))
+	RootCmd.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+		return setupLogging(logLevel, logFormat)
+	}
+}
+
+func envOr(name, value string) string {
+	if v, ok := os.LookupEnv(name); ok && v != "" {
+		return v
+	}
+	return value
+}
+
+// setupLogging sets the level and format of the logs
+func setupLogging(level, format string) error {
+	l, err := logging.LevelFromString(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q, expected debug, info, warn, error, fatal or panic", level)
+	}
+
+	// the logs go to stderr, leaving stdout to the command output
+	switch format {
+	case "text":
+		logging.SetOutputTo(os.Stderr)
+	case "json":
+		// the logger only formats text, the json lines are written by a hook instead
+		logging.Disable()
+		logging.AddHook(&jsonHook{out: os.Stderr, formatter: &logrus.JSONFormatter{}})
+	default:
+		return fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+
+	logging.SetLevel(l)
+	return nil
+}
+
+// jsonHook writes the log entries as json lines
+type jsonHook struct {
+	out       io.Writer
+	formatter logrus.Formatter
+}
+
+func (h *jsonHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *jsonHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.out.Write(line)
+	return err
+}
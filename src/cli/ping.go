@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	pingCmd.Flags().StringVar(&message, "message", "", "Text sent to the device before the random nonce.")
+	pingCmd.Flags().BoolVar(&buttonProtection, "buttonProtection", false, "Ask the device to wait for a button press before answering.")
+	pingCmd.Flags().BoolVar(&pinProtection, "pinProtection", false, "Ask the device to check the PIN code, if one is set, before answering.")
+	pingCmd.Flags().BoolVar(&passphraseProtection, "passphraseProtection", false, "Ask the device to check the passphrase, if enabled, before answering.")
+	pingCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Time to wait for each answer, 0 to wait forever.")
+	pingCmd.Flags().IntVar(&count, "count", 1, "Number of pings sent, one per second.")
+	pingCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check the device answers, measuring its latency.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if count < 1 {
+			return fmt.Errorf("count must be at least 1")
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		opts := skyWallet.PingOptions{
+			ButtonProtection:     buttonProtection,
+			PinProtection:        pinProtection,
+			PassphraseProtection: passphraseProtection,
+			ReadPin:              readPinMatrix,
+			ReadPassphrase:       readPassphrase,
+		}
+
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				time.Sleep(time.Second)
+			}
+
+			result, err := pingDevice(device, opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Answer from device: message=%q latency=%s time=%s\n", result.Message, result.Latency, result.Duration)
+		}
+		return nil
+	},
+}
+
+// pingDevice pings the device waiting at most the timeout flag for its answer
+func pingDevice(device *skyWallet.Device, opts skyWallet.PingOptions) (*skyWallet.PingResult, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result, err := device.Ping(ctx, message, opts)
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("device did not answer within %s", timeout)
+	}
+	return result, err
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
+)
+
+// readPinMatrix prompts for the PIN code encoded with the matrix shown on the device
+func readPinMatrix() (string, error) {
+	var pinEnc string
+	fmt.Printf("PinMatrixRequest response: ")
+	fmt.Scanln(&pinEnc)
+	return pinEnc, nil
+}
+
+// readPassphrase prompts for the wallet passphrase
+func readPassphrase() (string, error) {
+	var passphrase string
+	fmt.Printf("Input passphrase: ")
+	fmt.Scanln(&passphrase)
+	return passphrase, nil
+}
+
+// readConfirmation asks a yes or no question, anything but yes is a no
+func readConfirmation(question string) (bool, error) {
+	var answer string
+	fmt.Printf("%s [y/N]: ", question)
+	if _, err := fmt.Scanln(&answer); err == io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// readRecoveryWord prompts for a mnemonic word until it matches a single
+// BIP-39 word, completing prefixes and suggesting corrections for typos
+func readRecoveryWord() (string, error) {
+	for {
+		fmt.Printf("Word: ")
+		// the whole line is read, so that extra words are not left for the next prompt
+		line, err := readLine()
+		if err != nil {
+			return "", err
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) != 1 {
+			fmt.Println("Enter one word at a time")
+			continue
+		}
+		input := fields[0]
+
+		matches := bip39.Complete(input)
+		switch {
+		case len(matches) == 1:
+			if matches[0] != input {
+				fmt.Printf("Using %s\n", matches[0])
+			}
+			return matches[0], nil
+		case len(matches) > 1:
+			if bip39.IsWord(input) {
+				return input, nil
+			}
+			fmt.Printf("Ambiguous word, did you mean: %s\n", strings.Join(matches, ", "))
+		default:
+			if suggestions := bip39.Suggest(input); len(suggestions) > 0 {
+				fmt.Printf("Unknown word, did you mean: %s\n", strings.Join(suggestions, ", "))
+			} else {
+				fmt.Println("Unknown word, please try again")
+			}
+		}
+	}
+}
+
+// readDiceRolls prompts for dice rolls until the user enters needed rolls
+func readDiceRolls(needed int) (string, error) {
+	fmt.Printf("Enter %d more dice rolls: ", needed)
+	return readLine()
+}
+
+// readLine reads a line from the standard input a byte at a time,
+// so that no input is buffered away from the other prompts
+func readLine() (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	provisionCmd.Flags().StringVar(&profilePath, "profile", "", "Path to the json or yaml provisioning profile.")
+	provisionCmd.Flags().StringVar(&reportDir, "reportDir", "", "Directory to save the provisioning report as <DeviceId>.json.")
+	provisionCmd.Flags().BoolVar(&wipeDevice, "wipe", false, "Wipe an already initialized device first, destroying its seed.")
+	provisionCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var provisionCmd = &cobra.Command{
+	Use:   "provision",
+	Short: "Configure the device as described by a provisioning profile.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if profilePath == "" {
+			return fmt.Errorf("--profile is required")
+		}
+		profile, err := skyWallet.LoadProvisionProfile(profilePath)
+		if err != nil {
+			return err
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		report, provisionErr := device.Provision(*profile, wipeDevice, readPinMatrix)
+		if report != nil {
+			data, err := json.MarshalIndent(report, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+
+			if reportDir != "" && report.DeviceID != "" {
+				path := filepath.Join(reportDir, report.DeviceID+".json")
+				if err := ioutil.WriteFile(path, data, 0644); err != nil {
+					return err
+				}
+			}
+		}
+		return provisionErr
+	},
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/spf13/cobra"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func init() {
+	rawCmd.Flags().StringVar(&scriptFile, "script", "", "File with one message per line, sent in order until the device answers with a Failure.")
+	rawCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var rawCmd = &cobra.Command{
+	Use:   "raw [MessageType] [body]",
+	Short: "Send any protocol message and print the answers as JSON, for firmware development.",
+	Long: `Send a message of the given MessageType, i.e. Ping or PassphraseStateRequest, with a
+body in JSON, i.e. '{"message": "hello"}', or in protobuf text format, i.e. 'message: "hello"'.
+Every message received is printed as a JSON line. Button, PIN, passphrase and word requests
+are answered until the device sends any other message.`,
+	Args: cobra.RangeArgs(0, 2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		var steps []skyWallet.RawStep
+		switch {
+		case scriptFile != "" && len(args) > 0:
+			return fmt.Errorf("a message and a script cannot be sent together")
+		case scriptFile != "":
+			f, err := os.Open(scriptFile)
+			if err != nil {
+				return err
+			}
+			steps, err = skyWallet.ParseRawScript(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %v", scriptFile, err)
+			}
+		case len(args) > 0:
+			kind, err := skyWallet.ParseMessageType(args[0])
+			if err != nil {
+				return err
+			}
+			m, err := skyWallet.ParseMessage(kind, strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+			steps = []skyWallet.RawStep{{Message: m}}
+		default:
+			return fmt.Errorf("a MessageType or a script is required")
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		printMessage := func(msg wire.Message) error {
+			b, err := skyWallet.MessageJSON(msg)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		for _, step := range steps {
+			log.Printf("Sending %s", proto.MessageName(step.Message))
+			msg, err := device.Exchange(step.Message, printMessage, readPinMatrix, readPassphrase, readRecoveryWord)
+			if err != nil {
+				return err
+			}
+			if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
+				if step.Line > 0 {
+					return fmt.Errorf("%s line %d: device failure: %w", scriptFile, step.Line, skyWallet.DecodeFailure(msg))
+				}
+				return fmt.Errorf("device failure: %w", skyWallet.DecodeFailure(msg))
+			}
+		}
+		return nil
+	},
+}
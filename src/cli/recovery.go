@@ -53,15 +53,17 @@ var recoveryCmd = &cobra.Command{
 
 			for msg.Kind == uint16(messages.MessageType_MessageType_WordRequest) {
 				var word string
-				fmt.Printf("Word: ")
-				fmt.Scanln(&word)
+				word, err = readRecoveryWord()
+				if err != nil {
+					return err
+				}
 				msg, err = device.WordAck(word)
 				if err != nil {
 					return err
 				}
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	resetCmd.Flags().BoolVar(&displayRandom, "displayRandom", false, "Show the device internal entropy on its screen before it is mixed with the host entropy.")
+	resetCmd.Flags().IntVar(&strength, "strength", 256, "Strength of the seed in bits (128 | 192 | 256), for 12, 18 or 24 words.")
+	resetCmd.Flags().BoolVar(&usePassphrase, "usePassphrase", false, "Configure a passphrase")
+	resetCmd.Flags().BoolVar(&pinProtection, "pinProtection", false, "Configure a PIN code, entered during the reset.")
+	resetCmd.Flags().StringVar(&language, "language", "", "Device language.")
+	resetCmd.Flags().StringVar(&label, "label", "", "Label to identify the device.")
+	resetCmd.Flags().IntVar(&u2fCounter, "u2fCounter", 0, "Initial value of the U2F counter.")
+	resetCmd.Flags().BoolVar(&skipBackup, "skipBackup", false, "Skip the seed backup, to do it later with the backup command.")
+	resetCmd.Flags().StringVar(&entropySource, "entropySource", "rand", "Source of the host entropy: rand, dice, file:<path> of a hardware RNG or fixed:<hex> for tests.")
+	resetCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Ask the device to generate a seed from its entropy mixed with host entropy and configure itself.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if strength < 0 || u2fCounter < 0 {
+			return fmt.Errorf("strength and u2fCounter must not be negative")
+		}
+		source, err := parseEntropySource(entropySource)
+		if err != nil {
+			return err
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		device.SetEntropySource(source)
+
+		msg, err := device.ResetDevice(skyWallet.ResetDeviceOptions{
+			DisplayRandom:        displayRandom,
+			Strength:             uint32(strength),
+			PassphraseProtection: usePassphrase,
+			PinProtection:        pinProtection,
+			Language:             language,
+			Label:                label,
+			U2FCounter:           uint32(u2fCounter),
+			SkipBackup:           skipBackup,
+		})
+		if err != nil {
+			return err
+		}
+
+		for {
+			switch msg.Kind {
+			case uint16(messages.MessageType_MessageType_Success):
+				responseMsg, err := skyWallet.DecodeSuccessMsg(msg)
+				if err != nil {
+					return err
+				}
+				fmt.Println(responseMsg)
+				return nil
+			case uint16(messages.MessageType_MessageType_Failure):
+				return fmt.Errorf("device failure: %w", skyWallet.DecodeFailure(msg))
+			case uint16(messages.MessageType_MessageType_PinMatrixRequest):
+				pinEnc, err := readPinMatrix()
+				if err != nil {
+					return err
+				}
+				msg, err = device.PinMatrixAck(pinEnc)
+				if err != nil {
+					return err
+				}
+			case uint16(messages.MessageType_MessageType_ButtonRequest):
+				msg, err = device.ButtonAck()
+				if err != nil {
+					return err
+				}
+			default:
+				_, err := skyWallet.DecodeSuccess(msg)
+				return err
+			}
+		}
+	},
+}
+
+// parseEntropySource returns the EntropySource described by the entropySource flag
+func parseEntropySource(s string) (skyWallet.EntropySource, error) {
+	switch {
+	case s == "rand":
+		return skyWallet.RandEntropy{}, nil
+	case s == "dice":
+		return skyWallet.DiceEntropy{ReadRolls: readDiceRolls}, nil
+	case strings.HasPrefix(s, "file:"):
+		return skyWallet.FileEntropy{Path: strings.TrimPrefix(s, "file:")}, nil
+	case strings.HasPrefix(s, "fixed:"):
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "fixed:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixed entropy: %v", err)
+		}
+		return skyWallet.FixedEntropy(b), nil
+	default:
+		return nil, fmt.Errorf("unknown entropy source %q", s)
+	}
+}
@@ -1,5 +1,7 @@
 package cli
 
+import "time"
+
 var (
 	deviceType string
 	addressN int
@@ -25,4 +27,31 @@ var (
 	addressIndex []int
 	entropyBytes int
 	signature string
+	onDevice bool
+	profilePath string
+	reportDir string
+	filePath string
+	signatureFile string
+	gapLimit int
+	batchSize int
+	nodeURL string
+	cacheFile string
+	walletFile string
+	auditLog string
+	inverseQR bool
+	scriptFile string
+	buttonProtection bool
+	pinProtection bool
+	passphraseProtection bool
+	timeout time.Duration
+	count int
+	hdNode string
+	pinCode string
+	skipChecksum bool
+	displayRandom bool
+	strength int
+	u2fCounter int
+	skipBackup bool
+	entropySource string
+	wipeDevice bool
 )
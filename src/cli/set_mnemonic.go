@@ -44,7 +44,7 @@ var setMnemonicCmd = &cobra.Command{
 				}
 			}
 
-			responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+			responseMsg, err := skyWallet.DecodeSuccess(msg)
 			if err != nil {
 				return err
 			}
@@ -40,19 +40,12 @@ var setPinCode = &cobra.Command{
 			for {
 				switch msg.Kind {
 				case uint16(messages.MessageType_MessageType_Success):
-					responseMsg, err := skyWallet.DecodeSuccessOrFailMsg(msg)
+					responseMsg, err := skyWallet.DecodeSuccess(msg)
 					if err != nil {
 						return err
 					}
 					fmt.Println(responseMsg)
 					return nil
-				case uint16(messages.MessageType_MessageType_Failure):
-					failMsg, err := skyWallet.DecodeFailMsg(msg)
-					if err != nil {
-						return err
-					}
-					fmt.Println(failMsg)
-					return nil
 				case uint16(messages.MessageType_MessageType_PinMatrixRequest):
 					var pinEnc string
 					fmt.Printf("PinMatrixRequest response: ")
@@ -66,6 +59,10 @@ var setPinCode = &cobra.Command{
 					if err != nil {
 						return err
 					}
+				default:
+					// a Failure is returned as a device error, any other message as a protocol error
+					_, err := skyWallet.DecodeSuccess(msg)
+					return err
 				}
 			}
 		},
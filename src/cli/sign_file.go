@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	signFileCmd.Flags().StringVar(&filePath, "file", "", "File to sign.")
+	signFileCmd.Flags().IntVar(&addressN, "addressN", 0, "Index of the address that will issue the signature. Assume 0 if not set.")
+	signFileCmd.Flags().StringVar(&signatureFile, "signatureFile", "", "Write the signature bundle to this file instead of stdout.")
+	signFileCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var signFileCmd = &cobra.Command{
+	Use:   "signFile",
+	Short: "Ask the device to sign the SHA-256 digest of a file and output a detached signature.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if filePath == "" {
+			return fmt.Errorf("file is required")
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		signature, err := device.SignFile(filePath, addressN, readPinMatrix, readPassphrase)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(signature, "", "    ")
+		if err != nil {
+			return err
+		}
+
+		if signatureFile == "" {
+			fmt.Println(string(b))
+			return nil
+		}
+		return ioutil.WriteFile(signatureFile, b, 0644)
+	},
+}
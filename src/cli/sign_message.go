@@ -14,6 +14,7 @@ func init() {
 	signMessageCmd.Flags().IntVar(&addressN, "addressN", 0, "Index of the address that will issue the signature. Assume 0 if not set.")
 	signMessageCmd.Flags().StringVar(&message, "message", "", "The message that the signature claims to be signing.")
 	signMessageCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+	signMessageCmd.Flags().StringVar(&coinTypeStr, "coinTypeStr", "SKY", "Coin type to use on hardware-wallet.")
 }
 
 
@@ -21,6 +22,10 @@ var signMessageCmd = &cobra.Command{
 		Use:   "signMessage",
 		Short: "Ask the device to sign a message using the secret key at given index.",
 		RunE: func(_ *cobra.Command, _ []string) error {
+			coinType, err := skyWallet.CoinTypeFromString(coinTypeStr)
+			if err != nil {
+				return err
+			}
 
 			device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
 			if device == nil {
@@ -37,7 +42,7 @@ var signMessageCmd = &cobra.Command{
 
 			var signature string
 
-			msg, err := device.SignMessage(addressN, message)
+			msg, err := device.SignMessage(addressN, message, coinType)
 			if err != nil {
 				return err
 			}
@@ -81,17 +86,10 @@ var signMessageCmd = &cobra.Command{
 				}
 			}
 
-			if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage) {
-				signature, err = skyWallet.DecodeResponseSkycoinSignMessage(msg)
-				if err != nil {
-					return err
-				}
-			} else {
-				failMsg, err := skyWallet.DecodeFailMsg(msg)
-				if err != nil {
-					return err
-				}
-				return fmt.Errorf("failed with: %s", failMsg)
+			// a Failure is returned as a device error, any other message as a protocol error
+			signature, err = skyWallet.DecodeResponseSignMessage(msg, coinType)
+			if err != nil {
+				return err
 			}
 
 			fmt.Println(signature)
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/hardware-wallet-go/src/qrcode"
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	verifyAddressCmd.Flags().IntVar(&addressN, "addressN", 0, "Index of the address to verify. Assume 0 if not set.")
+	verifyAddressCmd.Flags().StringVar(&coinTypeStr, "coinTypeStr", "SKY", "Coin type to use on hardware-wallet.")
+	verifyAddressCmd.Flags().StringVar(&auditLog, "auditLog", defaultAuditLogPath(), "File the verification results are appended to.")
+	verifyAddressCmd.Flags().BoolVar(&inverseQR, "inverseQR", false, "Draw the QR code for terminals with a light background.")
+	verifyAddressCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var verifyAddressCmd = &cobra.Command{
+	Use:   "verifyAddress",
+	Short: "Show a receive address on the host and on the device screen and record whether they match.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		coinType, err := skyWallet.CoinTypeFromString(coinTypeStr)
+		if err != nil {
+			return err
+		}
+		if addressN < 0 {
+			return fmt.Errorf("addressN must not be negative")
+		}
+
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		show := func(address string) error {
+			code, err := qrcode.Encode([]byte(address))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Address #%d: %s\n\n%s\n", addressN, address, code.Terminal(inverseQR))
+			fmt.Println("Compare it with the address on the device screen, accept it on the device only if they are the same.")
+			return nil
+		}
+
+		verification, err := device.VerifyAddress(uint32(addressN), coinType, show, readPinMatrix, readPassphrase)
+		if verification == nil {
+			return err
+		}
+		if err == nil {
+			verification.UserMatch, err = readConfirmation("Did the device show the same address?")
+			if err != nil {
+				return err
+			}
+		}
+
+		if logErr := skyWallet.AppendAddressVerification(auditLog, *verification); logErr != nil {
+			return fmt.Errorf("failed to record the verification in %s: %v", auditLog, logErr)
+		}
+		if err != nil {
+			return fmt.Errorf("address not verified: %v, recorded in %s", err, auditLog)
+		}
+		if !verification.Verified() {
+			return fmt.Errorf("address not verified, recorded in %s", auditLog)
+		}
+
+		fmt.Printf("Address verified, recorded in %s\n", auditLog)
+		return nil
+	},
+}
+
+// defaultAuditLogPath returns the address verification log in the user home directory
+func defaultAuditLogPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "address-verification.log"
+	}
+	return filepath.Join(home, ".skycoin-hw-cli", "address-verification.log")
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	verifyBackupCmd.Flags().IntVar(&wordCount, "wordCount", 12, "Number of words (12 | 24) of the seed backup")
+	verifyBackupCmd.Flags().BoolVar(&usePassphrase, "usePassphrase", false, "The seed backup is used with a passphrase")
+	verifyBackupCmd.Flags().StringVar(&deviceType, "deviceType", "USB", "Device type to send instructions to, hardware wallet (USB) or emulator.")
+}
+
+var verifyBackupCmd = &cobra.Command{
+	Use:   "verifyBackup",
+	Short: "Check that a written down seed matches the one in the device without modifying it.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		device := skyWallet.NewDevice(skyWallet.DeviceTypeFromString(deviceType))
+		if device == nil {
+			return fmt.Errorf("failed to create device")
+		}
+		defer device.Close()
+
+		if os.Getenv("AUTO_PRESS_BUTTONS") == "1" && device.Driver.DeviceType() == skyWallet.DeviceTypeEmulator && runtime.GOOS == "linux" {
+			err := device.SetAutoPressButton(true, skyWallet.ButtonRight)
+			if err != nil {
+				return err
+			}
+		}
+
+		match, responseMsg, err := device.VerifyBackup(uint32(wordCount), &usePassphrase, readPinMatrix, readRecoveryWord)
+		if err != nil {
+			return err
+		}
+
+		if !match {
+			return errors.New("backup verification failed: " + responseMsg)
+		}
+
+		fmt.Println("Backup verified: " + responseMsg)
+		return nil
+	},
+}
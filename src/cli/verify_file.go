@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	skyWallet "github.com/skycoin/hardware-wallet-go/src/skywallet"
+)
+
+func init() {
+	verifyFileCmd.Flags().StringVar(&filePath, "file", "", "File to verify.")
+	verifyFileCmd.Flags().StringVar(&signatureFile, "signatureFile", "", "Signature bundle written by signFile.")
+	verifyFileCmd.Flags().StringVar(&address, "address", "", "Address expected to have signed the file.")
+}
+
+var verifyFileCmd = &cobra.Command{
+	Use:   "verifyFile",
+	Short: "Check a file matches a detached signature written by signFile.",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if filePath == "" || signatureFile == "" || address == "" {
+			return fmt.Errorf("file, signatureFile and address are required")
+		}
+
+		b, err := ioutil.ReadFile(signatureFile)
+		if err != nil {
+			return err
+		}
+
+		var signature skyWallet.FileSignature
+		if err := json.Unmarshal(b, &signature); err != nil {
+			return err
+		}
+
+		if err := skyWallet.VerifyFileSignature(filePath, signature, address); err != nil {
+			return err
+		}
+
+		fmt.Println(signature.Address)
+		return nil
+	},
+}
@@ -0,0 +1,394 @@
+// Package qrcode encodes short texts, like wallet addresses, as QR codes
+// and renders them with block characters for a terminal.
+//
+// Only what addresses need is supported: byte mode, error correction
+// level M and symbol versions 1 to 6 (up to 106 bytes).
+package qrcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTooLong is returned if the data does not fit in the largest supported symbol
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// quietZone is the number of light modules required around the symbol
+const quietZone = 4
+
+// version describes the codewords of a symbol version at error correction level M
+type version struct {
+	// ecPerBlock is the number of error correction codewords of each block
+	ecPerBlock int
+	// blocks is the number of blocks, all of them have the same length
+	blocks int
+	// dataPerBlock is the number of data codewords of each block
+	dataPerBlock int
+	// alignment is the center of the alignment pattern, 0 if there is none
+	alignment int
+}
+
+// versions lists the supported versions, the version number is the index plus one
+var versions = []version{
+	{ecPerBlock: 10, blocks: 1, dataPerBlock: 16},
+	{ecPerBlock: 16, blocks: 1, dataPerBlock: 28, alignment: 18},
+	{ecPerBlock: 26, blocks: 1, dataPerBlock: 44, alignment: 22},
+	{ecPerBlock: 18, blocks: 2, dataPerBlock: 32, alignment: 26},
+	{ecPerBlock: 24, blocks: 2, dataPerBlock: 43, alignment: 30},
+	{ecPerBlock: 16, blocks: 4, dataPerBlock: 27, alignment: 34},
+}
+
+// Code is an encoded QR code symbol
+type Code struct {
+	// Size is the number of modules of a side
+	Size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode encodes data as a QR code in byte mode with error correction level M,
+// choosing the smallest version and the mask with the lowest penalty
+func Encode(data []byte) (*Code, error) {
+	for i, v := range versions {
+		// mode indicator and character count take two bytes
+		if len(data)+2 <= v.blocks*v.dataPerBlock {
+			return encode(data, i+1, v, -1), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// Dark tells whether the module at column x and row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Terminal renders the code with half block characters, two module rows per line.
+// The blocks draw the light modules, which suits terminals with a dark background.
+// inverse draws the dark modules instead, for terminals with a light background.
+func (c *Code) Terminal(inverse bool) string {
+	// filled tells whether the module is drawn, the quiet zone is light
+	filled := func(x, y int) bool {
+		dark := false
+		if x >= 0 && y >= 0 && x < c.Size && y < c.Size {
+			dark = c.modules[y][x]
+		}
+		return dark == inverse
+	}
+
+	var b strings.Builder
+	for y := -quietZone; y < c.Size+quietZone; y += 2 {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			top, bottom := filled(x, y), filled(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// encode builds the symbol of the given version, mask -1 picks the best mask
+func encode(data []byte, number int, v version, mask int) *Code {
+	size := 17 + 4*number
+	c := &Code{
+		Size:     size,
+		modules:  make([][]bool, size),
+		function: make([][]bool, size),
+	}
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+
+	c.drawFunctionPatterns(v)
+	c.drawCodewords(interleave(v, dataCodewords(data, v.blocks*v.dataPerBlock)))
+
+	if mask < 0 {
+		minPenalty := -1
+		for m := 0; m < 8; m++ {
+			c.applyMask(m)
+			c.drawFormat(m)
+			if p := c.penalty(); minPenalty < 0 || p < minPenalty {
+				minPenalty = p
+				mask = m
+			}
+			c.applyMask(m)
+		}
+	}
+	c.applyMask(mask)
+	c.drawFormat(mask)
+	return c
+}
+
+// dataCodewords encodes data in byte mode and pads it to n codewords
+func dataCodewords(data []byte, n int) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(uint32(len(data)), 8)
+	for _, b := range data {
+		bits.append(uint32(b), 8)
+	}
+
+	terminator := n*8 - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	if rem := len(bits) % 8; rem != 0 {
+		bits.append(0, 8-rem)
+	}
+	for pad := uint32(0xec); len(bits) < n*8; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// interleave splits the data in blocks, appends their error correction
+// codewords and interleaves them as the symbol expects
+func interleave(v version, data []byte) []byte {
+	divisor := reedSolomonDivisor(v.ecPerBlock)
+	blocks := make([][]byte, v.blocks)
+	for i := range blocks {
+		block := data[i*v.dataPerBlock : (i+1)*v.dataPerBlock]
+		blocks[i] = append(append([]byte{}, block...), reedSolomonRemainder(block, divisor)...)
+	}
+
+	result := make([]byte, 0, len(data)+v.blocks*v.ecPerBlock)
+	for i := 0; i < v.dataPerBlock+v.ecPerBlock; i++ {
+		for _, block := range blocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// set sets a function module, which masks do not change
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns
+// and reserves the format areas
+func (c *Code) drawFunctionPatterns(v version) {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	if v.alignment != 0 {
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				c.set(v.alignment+dx, v.alignment+dy, max(abs(dx), abs(dy)) != 1)
+			}
+		}
+	}
+
+	// the format is drawn for each mask, this only reserves its modules
+	c.drawFormat(0)
+}
+
+// drawFinder draws a finder pattern and its separator around the center x, y
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Size || yy >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// formatBits returns the 15 format bits of level M and mask
+func formatBits(mask int) uint32 {
+	// level M is encoded as 00
+	data := uint32(mask)
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormat draws both copies of the format bits of mask
+func (c *Code) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 != 0
+	}
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// drawCodewords places the codewords in the zigzag order of the symbol,
+// the remainder bits are left light
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.modules[y][x] = (codewords[i/8]>>uint(7-i%8))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask, applying it twice undoes it
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !c.function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to read, masks are chosen to minimize it
+func (c *Code) penalty() int {
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+
+	finderLike := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < c.Size; y++ {
+			run := 1
+			for x := 1; x <= c.Size; x++ {
+				if x < c.Size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for x := 0; x+len(finderLike) <= c.Size; x++ {
+				forward, backward := true, true
+				for i, dark := range finderLike {
+					forward = forward && at(x+i, y, vertical) == dark
+					backward = backward && at(x+len(finderLike)-1-i, y, vertical) == dark
+				}
+				if forward {
+					penalty += 40
+				}
+				if backward {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				m := c.modules[y][x]
+				if c.modules[y-1][x] == m && c.modules[y][x-1] == m && c.modules[y-1][x-1] == m {
+					penalty += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (c.Size * c.Size)
+	return penalty + abs(percent-50)/5*10
+}
+
+// bitBuffer accumulates bits, most significant first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
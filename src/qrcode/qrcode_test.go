@@ -0,0 +1,93 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ec := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	require.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ec)
+}
+
+func TestFormatBits(t *testing.T) {
+	require.Equal(t, uint32(0x5412), formatBits(0))
+	require.Equal(t, uint32(0x4aa0), formatBits(7))
+}
+
+func TestEncode(t *testing.T) {
+	// "hello world" in byte mode, level M, mask 2
+	expected := []string{
+		"#######..#.##.#######",
+		"#.....#...#...#.....#",
+		"#.###.#.####..#.###.#",
+		"#.###.#.###.#.#.###.#",
+		"#.###.#.#.#.#.#.###.#",
+		"#.....#.#..#..#.....#",
+		"#######.#.#.#.#######",
+		"........#.#..........",
+		"#.#####..#.#..#####..",
+		".##.##.#.#.########.#",
+		"#.#.####.##.###..###.",
+		"#.#..#...#.###..###..",
+		"...#.#####..###.....#",
+		"........#.#.#...##..#",
+		"#######....#..#...##.",
+		"#.....#.#....#.#.####",
+		"#.###.#.#..#..##....#",
+		"#.###.#.##..######...",
+		"#.###.#.##..#..#..#..",
+		"#.....#..##.##..###..",
+		"#######.##.##.#.#..#.",
+	}
+
+	c, err := Encode([]byte("hello world"))
+	require.NoError(t, err)
+	require.Equal(t, len(expected), c.Size)
+	for y, row := range expected {
+		for x, m := range row {
+			require.Equal(t, m == '#', c.Dark(x, y), "module %d,%d", x, y)
+		}
+	}
+}
+
+func TestEncodeVersions(t *testing.T) {
+	tt := []struct {
+		length int
+		size   int
+	}{
+		{length: 1, size: 21},
+		{length: 14, size: 21},
+		{length: 15, size: 25},
+		{length: 35, size: 29},
+		{length: 62, size: 33},
+		{length: 84, size: 37},
+		{length: 106, size: 41},
+	}
+
+	for _, tc := range tt {
+		c, err := Encode([]byte(strings.Repeat("a", tc.length)))
+		require.NoError(t, err)
+		require.Equal(t, tc.size, c.Size, "length %d", tc.length)
+	}
+
+	_, err := Encode([]byte(strings.Repeat("a", 107)))
+	require.Equal(t, ErrTooLong, err)
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := Encode([]byte("2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(false), "\n"), "\n")
+	require.Len(t, lines, (c.Size+2*quietZone+1)/2)
+	for _, line := range lines {
+		require.Equal(t, c.Size+2*quietZone, len([]rune(line)))
+	}
+	// the quiet zone is light, so it is drawn unless inverse
+	require.Equal(t, strings.Repeat("█", c.Size+2*quietZone), lines[0])
+	require.Equal(t, strings.Repeat(" ", c.Size+2*quietZone), strings.Split(c.Terminal(true), "\n")[0])
+}
@@ -0,0 +1,43 @@
+package qrcode
+
+// gfMultiply multiplies two elements of GF(2^8) modulo the QR code polynomial 0x11d
+func gfMultiply(x, y byte) byte {
+	var z uint32
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= uint32((y>>uint(i))&1) * uint32(x)
+	}
+	return byte(z)
+}
+
+// reedSolomonDivisor returns the coefficients of the generator polynomial of
+// degree n, from the highest power to the lowest, the leading 1 is omitted
+func reedSolomonDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of data
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
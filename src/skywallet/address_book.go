@@ -0,0 +1,261 @@
+package skywallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+)
+
+const (
+	// DefaultGapLimit is the number of consecutive unused addresses that ends a scan
+	DefaultGapLimit = 20
+	// DefaultDiscoveryBatchSize is the number of addresses asked to the device at once
+	DefaultDiscoveryBatchSize = 20
+)
+
+var (
+	// ErrInvalidDiscoveryOptions is returned if the gap limit or the batch size is negative
+	ErrInvalidDiscoveryOptions = errors.New("gap limit and batch size must be greater than 0")
+)
+
+// WalletKey identifies the addresses of a device wallet.
+// A device has a different wallet for each passphrase, PassphraseState tells them apart.
+type WalletKey struct {
+	DeviceID        string   `json:"device_id"`
+	PassphraseState string   `json:"passphrase_state,omitempty"`
+	CoinType        CoinType `json:"coin_type"`
+}
+
+func (k WalletKey) String() string {
+	return strings.Join([]string{k.DeviceID, k.PassphraseState, k.CoinType.String()}, "/")
+}
+
+// CachedWallet holds the addresses already derived for a wallet, in index order
+type CachedWallet struct {
+	WalletKey
+	Addresses []string `json:"addresses"`
+}
+
+// AddressBook caches the addresses derived by devices in a local file
+// so they do not need to be asked again to the device
+type AddressBook struct {
+	path    string
+	Wallets map[string]*CachedWallet `json:"wallets"`
+}
+
+// LoadAddressBook reads the address book stored at path.
+// An empty address book is returned if the file does not exist yet.
+func LoadAddressBook(path string) (*AddressBook, error) {
+	book := &AddressBook{
+		path:    path,
+		Wallets: make(map[string]*CachedWallet),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %v", path, err)
+	}
+	if book.Wallets == nil {
+		book.Wallets = make(map[string]*CachedWallet)
+	}
+	return book, nil
+}
+
+// Save writes the address book to its file
+func (b *AddressBook) Save() error {
+	data, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// Addresses returns the cached addresses of the wallet
+func (b *AddressBook) Addresses(key WalletKey) []string {
+	if w, ok := b.Wallets[key.String()]; ok {
+		return w.Addresses
+	}
+	return nil
+}
+
+// AddressDeriver derives n addresses of a wallet starting at startIndex
+type AddressDeriver func(startIndex, n uint32) ([]string, error)
+
+// BalanceLookup tells the balance of addresses, it can be backed by a node or a stub
+type BalanceLookup interface {
+	// Balances returns the confirmed coins of each address, addresses without coins may be missing
+	Balances(addresses []string) (map[string]uint64, error)
+}
+
+// StaticBalances is a BalanceLookup answering from a fixed map
+type StaticBalances map[string]uint64
+
+// Balances returns the balance of the addresses found in the map
+func (s StaticBalances) Balances(addresses []string) (map[string]uint64, error) {
+	balances := make(map[string]uint64, len(addresses))
+	for _, a := range addresses {
+		if coins, ok := s[a]; ok {
+			balances[a] = coins
+		}
+	}
+	return balances, nil
+}
+
+// NodeBalanceLookup is a BalanceLookup asking the REST API of a Skycoin node
+type NodeBalanceLookup struct {
+	// URL of the node, i.e. http://127.0.0.1:6420
+	URL    string
+	Client *http.Client
+}
+
+// Balances asks the node the confirmed balance of the addresses
+func (n NodeBalanceLookup) Balances(addresses []string) (map[string]uint64, error) {
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	endpoint := strings.TrimSuffix(n.URL, "/") + "/api/v1/balance?addrs=" + url.QueryEscape(strings.Join(addresses, ","))
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("node balance request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var balance struct {
+		Addresses map[string]struct {
+			Confirmed struct {
+				Coins uint64 `json:"coins"`
+			} `json:"confirmed"`
+		} `json:"addresses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&balance); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]uint64, len(balance.Addresses))
+	for a, b := range balance.Addresses {
+		balances[a] = b.Confirmed.Coins
+	}
+	return balances, nil
+}
+
+// DiscoveryOptions configures an address scan
+type DiscoveryOptions struct {
+	// GapLimit is the number of consecutive unused addresses that ends the scan
+	GapLimit int
+	// BatchSize is the number of addresses asked to the device at once
+	BatchSize int
+}
+
+// DiscoveredAddress is an address found by a scan
+type DiscoveredAddress struct {
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+	Coins   uint64 `json:"coins"`
+}
+
+// Discover scans the wallet addresses in index order until GapLimit consecutive
+// addresses have no balance. Addresses missing from the cache are derived in
+// batches and added to it, the caller saves the book afterwards.
+// Returns the addresses up to the last used one followed by the unused gap.
+func (b *AddressBook) Discover(key WalletKey, derive AddressDeriver, lookup BalanceLookup, opts DiscoveryOptions) ([]DiscoveredAddress, error) {
+	if opts.GapLimit == 0 {
+		opts.GapLimit = DefaultGapLimit
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultDiscoveryBatchSize
+	}
+	if opts.GapLimit < 0 || opts.BatchSize < 0 {
+		return nil, ErrInvalidDiscoveryOptions
+	}
+
+	wallet, ok := b.Wallets[key.String()]
+	if !ok {
+		wallet = &CachedWallet{WalletKey: key}
+		b.Wallets[key.String()] = wallet
+	}
+
+	var discovered []DiscoveredAddress
+	gap := 0
+	for index := 0; gap < opts.GapLimit; index += opts.BatchSize {
+		if len(wallet.Addresses) < index+opts.BatchSize {
+			start := len(wallet.Addresses)
+			addresses, err := derive(uint32(start), uint32(index+opts.BatchSize-start))
+			if err != nil {
+				return nil, err
+			}
+			wallet.Addresses = append(wallet.Addresses, addresses...)
+			if len(wallet.Addresses) < index+opts.BatchSize {
+				return nil, fmt.Errorf("device derived %d addresses, %d were asked", len(addresses), index+opts.BatchSize-start)
+			}
+		}
+
+		batch := wallet.Addresses[index : index+opts.BatchSize]
+		balances, err := lookup.Balances(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, a := range batch {
+			if gap >= opts.GapLimit {
+				break
+			}
+			discovered = append(discovered, DiscoveredAddress{
+				Index:   uint32(index + i),
+				Address: a,
+				Coins:   balances[a],
+			})
+			if balances[a] > 0 {
+				gap = 0
+			} else {
+				gap++
+			}
+		}
+	}
+	return discovered, nil
+}
+
+// AddressDeriver returns an AddressDeriver asking the device addresses of coinType.
+// readPin and readPassphrase are called when the device asks for them.
+func (d *Device) AddressDeriver(coinType CoinType, readPin, readPassphrase func() (string, error)) AddressDeriver {
+	return func(startIndex, n uint32) ([]string, error) {
+		msg, err := d.AddressGen(n, startIndex, false, coinType)
+		if err != nil {
+			return nil, err
+		}
+		msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_ResponseSkycoinAddress, readPin, readPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return DecodeResponseSkycoinAddress(msg)
+	}
+}
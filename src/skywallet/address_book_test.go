@@ -0,0 +1,84 @@
+package skywallet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeriver derives addresses named after their index and counts the derived addresses
+type fakeDeriver struct {
+	derived int
+}
+
+func (f *fakeDeriver) derive(startIndex, n uint32) ([]string, error) {
+	var addresses []string
+	for i := startIndex; i < startIndex+n; i++ {
+		addresses = append(addresses, fmt.Sprintf("addr%d", i))
+	}
+	f.derived += int(n)
+	return addresses, nil
+}
+
+func TestDiscover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addressbook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallets", "addresses.json")
+
+	book, err := LoadAddressBook(path)
+	require.NoError(t, err)
+
+	key := WalletKey{DeviceID: "ABCD", CoinType: SkycoinCoinType}
+	lookup := StaticBalances{"addr1": 10, "addr6": 5}
+	deriver := &fakeDeriver{}
+
+	discovered, err := book.Discover(key, deriver.derive, lookup, DiscoveryOptions{GapLimit: 5, BatchSize: 4})
+	require.NoError(t, err)
+	require.Len(t, discovered, 12)
+	require.Equal(t, DiscoveredAddress{Index: 1, Address: "addr1", Coins: 10}, discovered[1])
+	require.Equal(t, DiscoveredAddress{Index: 6, Address: "addr6", Coins: 5}, discovered[6])
+	require.Equal(t, "addr11", discovered[11].Address)
+	require.Equal(t, 12, deriver.derived)
+	require.NoError(t, book.Save())
+
+	// NOTE: a reloaded book answers from the cache
+	book, err = LoadAddressBook(path)
+	require.NoError(t, err)
+	require.Len(t, book.Addresses(key), 12)
+	cached, err := book.Discover(key, deriver.derive, lookup, DiscoveryOptions{GapLimit: 5, BatchSize: 4})
+	require.NoError(t, err)
+	require.Equal(t, discovered, cached)
+	require.Equal(t, 12, deriver.derived)
+
+	// NOTE: other passphrase wallets are cached apart
+	require.Empty(t, book.Addresses(WalletKey{DeviceID: "ABCD", PassphraseState: "other", CoinType: SkycoinCoinType}))
+
+	_, err = book.Discover(key, deriver.derive, lookup, DiscoveryOptions{GapLimit: -1})
+	require.Equal(t, ErrInvalidDiscoveryOptions, err)
+}
+
+func TestNodeBalanceLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/balance", r.URL.Path)
+		require.Equal(t, "addr0,addr1", r.URL.Query().Get("addrs"))
+		fmt.Fprint(w, `{
+			"confirmed": {"coins": 10000000, "hours": 20},
+			"addresses": {
+				"addr0": {"confirmed": {"coins": 0, "hours": 0}},
+				"addr1": {"confirmed": {"coins": 10000000, "hours": 20}}
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	balances, err := NodeBalanceLookup{URL: server.URL}.Balances([]string{"addr0", "addr1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"addr0": 0, "addr1": 10000000}, balances)
+}
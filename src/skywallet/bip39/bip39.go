@@ -0,0 +1,165 @@
+// Package bip39 validates BIP-39 mnemonics against the English wordlist and
+// helps users type them by completing and correcting words.
+package bip39
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	bitsPerWord = 11
+	// maxSuggestDistance is the largest edit distance for which a word is
+	// offered as a correction of a mistyped one
+	maxSuggestDistance = 2
+)
+
+var (
+	// ErrInvalidWordCount is returned if the mnemonic does not have 12 or 24 words
+	ErrInvalidWordCount = errors.New("mnemonic must have 12 or 24 words")
+	// ErrChecksumMismatch is returned if the mnemonic checksum bits do not match its entropy
+	ErrChecksumMismatch = errors.New("mnemonic checksum mismatch")
+)
+
+var wordIndex = func() map[string]int {
+	m := make(map[string]int, len(English))
+	for i, w := range English {
+		m[w] = i
+	}
+	return m
+}()
+
+// UnknownWordError is returned if a mnemonic word is not in the wordlist
+type UnknownWordError struct {
+	// Position of the word in the mnemonic, starting at 1
+	Position int
+	Word     string
+	// Suggestions contains close matches from the wordlist
+	Suggestions []string
+}
+
+func (e UnknownWordError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("word %d %q is not in the BIP-39 wordlist", e.Position, e.Word)
+	}
+	return fmt.Sprintf("word %d %q is not in the BIP-39 wordlist, did you mean: %s",
+		e.Position, e.Word, strings.Join(e.Suggestions, ", "))
+}
+
+// IsWord returns true if word belongs to the wordlist
+func IsWord(word string) bool {
+	_, ok := wordIndex[word]
+	return ok
+}
+
+// NormalizeMnemonic returns mnemonic lowercased with its words separated by single spaces,
+// the form ValidateMnemonic checks
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// ValidateMnemonic checks that mnemonic has 12 or 24 words from the wordlist
+// and that its checksum is correct
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words) != 12 && len(words) != 24 {
+		return ErrInvalidWordCount
+	}
+
+	// Pack the 11 bit word indexes into a bit string of
+	// len(words) * 11 bits, entropy followed by checksum
+	bits := make([]byte, (len(words)*bitsPerWord+7)/8)
+	for i, w := range words {
+		idx, ok := wordIndex[strings.ToLower(w)]
+		if !ok {
+			return UnknownWordError{
+				Position:    i + 1,
+				Word:        w,
+				Suggestions: Suggest(strings.ToLower(w)),
+			}
+		}
+		for b := 0; b < bitsPerWord; b++ {
+			if idx&(1<<uint(bitsPerWord-1-b)) != 0 {
+				pos := i*bitsPerWord + b
+				bits[pos/8] |= 1 << uint(7-pos%8)
+			}
+		}
+	}
+
+	checksumBits := len(words) * bitsPerWord / 33
+	entropyLen := (len(words)*bitsPerWord - checksumBits) / 8
+	hash := sha256.Sum256(bits[:entropyLen])
+
+	// The checksum is the first checksumBits bits of the entropy hash
+	// and fits in the byte right after the entropy
+	mask := byte(0xff << uint(8-checksumBits))
+	if bits[entropyLen]&mask != hash[0]&mask {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// Complete returns the words in the wordlist starting with prefix, sorted
+// alphabetically. Since BIP-39 words are unique in their first four letters,
+// any four letter prefix matches at most one word.
+func Complete(prefix string) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return nil
+	}
+	// English is sorted, so matches are contiguous
+	start := sort.SearchStrings(English, prefix)
+	var matches []string
+	for i := start; i < len(English) && strings.HasPrefix(English[i], prefix); i++ {
+		matches = append(matches, English[i])
+	}
+	return matches
+}
+
+// Suggest returns the words in the wordlist closest to word in edit distance,
+// to be offered as corrections of a typo. Nothing is returned if word
+// is already in the wordlist or no word is close enough.
+func Suggest(word string) []string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || IsWord(word) {
+		return nil
+	}
+
+	best := maxSuggestDistance + 1
+	var suggestions []string
+	for _, w := range English {
+		d := editDistance(word, w)
+		switch {
+		case d < best:
+			best = d
+			suggestions = []string{w}
+		case d == best:
+			suggestions = append(suggestions, w)
+		}
+	}
+	return suggestions
+}
+
+// editDistance computes the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,126 @@
+package bip39
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist(t *testing.T) {
+	require.Len(t, English, 2048)
+	require.Equal(t, "abandon", English[0])
+	require.Equal(t, "zoo", English[2047])
+	require.True(t, IsWord("thank"))
+	require.False(t, IsWord("thanks"))
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	tt := []struct {
+		name     string
+		mnemonic string
+		err      error
+	}{
+		{
+			name:     "12 words",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		},
+		{
+			name:     "12 words non zero entropy",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		},
+		{
+			name:     "12 words default test seed",
+			mnemonic: "cloud flower upset remain green metal below cup stem infant art thank",
+		},
+		{
+			name:     "24 words",
+			mnemonic: strings.Repeat("abandon ", 23) + "art",
+		},
+		{
+			name:     "24 words all ones",
+			mnemonic: strings.Repeat("zoo ", 23) + "vote",
+		},
+		{
+			name:     "extra whitespace and upper case",
+			mnemonic: "  Legal winner thank year wave sausage worth useful legal winner thank   yellow\n",
+		},
+		{
+			name:     "12 words bad checksum",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+			err:      ErrChecksumMismatch,
+		},
+		{
+			name:     "24 words bad checksum",
+			mnemonic: strings.Repeat("zoo ", 23) + "zoo",
+			err:      ErrChecksumMismatch,
+		},
+		{
+			name:     "wrong word count",
+			mnemonic: "abandon abandon abandon",
+			err:      ErrInvalidWordCount,
+		},
+		{
+			name:     "empty",
+			mnemonic: "",
+			err:      ErrInvalidWordCount,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.err, ValidateMnemonic(tc.mnemonic))
+		})
+	}
+}
+
+func TestNormalizeMnemonic(t *testing.T) {
+	require.Equal(t, "legal winner thank year", NormalizeMnemonic(" Legal  winner\tTHANK year\n"))
+	require.Equal(t, "", NormalizeMnemonic("  "))
+}
+
+func TestValidateMnemonicUnknownWord(t *testing.T) {
+	err := ValidateMnemonic("cloud flower upset remain green metal below cup stem infnat art thank")
+	require.Error(t, err)
+	uwErr, ok := err.(UnknownWordError)
+	require.True(t, ok)
+	require.Equal(t, 10, uwErr.Position)
+	require.Equal(t, "infnat", uwErr.Word)
+	require.Contains(t, uwErr.Suggestions, "infant")
+}
+
+func TestComplete(t *testing.T) {
+	tt := []struct {
+		prefix  string
+		matches []string
+	}{
+		{prefix: "", matches: nil},
+		{prefix: "xyz", matches: nil},
+		{prefix: "zo", matches: []string{"zone", "zoo"}},
+		{prefix: "aban", matches: []string{"abandon"}},
+		{prefix: "ABAN", matches: []string{"abandon"}},
+		{prefix: "thank", matches: []string{"thank"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.prefix, func(t *testing.T) {
+			require.Equal(t, tc.matches, Complete(tc.prefix))
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	require.Nil(t, Suggest("thank"))
+	require.Nil(t, Suggest(""))
+	require.Nil(t, Suggest("qqqqqqqqqq"))
+	require.Equal(t, []string{"floor", "flower"}, Suggest("flowr"))
+	require.Contains(t, Suggest("thnk"), "thank")
+}
+
+func TestEditDistance(t *testing.T) {
+	require.Equal(t, 0, editDistance("zoo", "zoo"))
+	require.Equal(t, 1, editDistance("zoo", "zo"))
+	require.Equal(t, 1, editDistance("zoo", "zon"))
+	require.Equal(t, 3, editDistance("", "zoo"))
+	require.Equal(t, 3, editDistance("kitten", "sitting"))
+}
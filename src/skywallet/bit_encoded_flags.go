@@ -1,8 +1,10 @@
 package skywallet
 
 import (
-	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 )
 
 // BitEncodedFlags allow you to work with bit field encoded in integer in a high level
@@ -17,6 +19,155 @@ type BitEncodedFlags interface {
 	HasRdpMemProtectEnabled() bool
 }
 
+// BitField describes a value packed in the bits of a BitEncodedFlags integer
+type BitField struct {
+	// Name of the struct field holding the value
+	Name string
+	// Offset of the lowest bit of the value
+	Offset uint
+	// Width in bits of the value
+	Width uint
+	// Kind of the struct field, reflect.Bool requires a width of one bit
+	// and unsigned integer kinds must be large enough to hold the value
+	Kind reflect.Kind
+}
+
+// mask returns the bits used by the field
+func (f BitField) mask() uint64 {
+	return ((1 << f.Width) - 1) << f.Offset
+}
+
+// BitSchema lists the fields packed in a BitEncodedFlags integer
+type BitSchema []BitField
+
+// UnknownBitsError is returned if the flags have bits set that no schema field describes
+type UnknownBitsError struct {
+	Bits uint64
+}
+
+func (e UnknownBitsError) Error() string {
+	return fmt.Sprintf("unknown flag bits set: %#x", e.Bits)
+}
+
+// Validate checks the fields are within 64 bits, do not overlap and have a supported kind
+func (s BitSchema) Validate() error {
+	var used uint64
+	for _, f := range s {
+		if f.Width == 0 || f.Offset+f.Width > 64 {
+			return fmt.Errorf("bit field %s does not fit in 64 bits", f.Name)
+		}
+		switch f.Kind {
+		case reflect.Bool:
+			if f.Width != 1 {
+				return fmt.Errorf("bit field %s is a bool and must be one bit wide", f.Name)
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return fmt.Errorf("bit field %s has unsupported kind %s", f.Name, f.Kind)
+		}
+		if used&f.mask() != 0 {
+			return fmt.Errorf("bit field %s overlaps another field", f.Name)
+		}
+		used |= f.mask()
+	}
+	return nil
+}
+
+// Mask returns the bits described by the schema
+func (s BitSchema) Mask() uint64 {
+	var mask uint64
+	for _, f := range s {
+		mask |= f.mask()
+	}
+	return mask
+}
+
+// Encode packs the schema fields of the struct pointed by v in an integer
+func (s BitSchema) Encode(v interface{}) (uint64, error) {
+	st := reflect.Indirect(reflect.ValueOf(v))
+	var flags uint64
+	for _, f := range s {
+		field, err := s.field(st, f)
+		if err != nil {
+			return 0, err
+		}
+		var value uint64
+		if f.Kind == reflect.Bool {
+			if field.Bool() {
+				value = 1
+			}
+		} else {
+			value = field.Uint()
+		}
+		if value > f.mask()>>f.Offset {
+			return 0, fmt.Errorf("bit field %s value %d does not fit in %d bits", f.Name, value, f.Width)
+		}
+		flags |= value << f.Offset
+	}
+	return flags, nil
+}
+
+// Decode fills the schema fields of the struct pointed by v from flags.
+// Known fields are decoded even if an UnknownBitsError is returned.
+func (s BitSchema) Decode(flags uint64, v interface{}) error {
+	st := reflect.ValueOf(v).Elem()
+	for _, f := range s {
+		field, err := s.field(st, f)
+		if err != nil {
+			return err
+		}
+		value := (flags & f.mask()) >> f.Offset
+		if f.Kind == reflect.Bool {
+			field.SetBool(value == 1)
+		} else {
+			field.SetUint(value)
+		}
+	}
+	if unknown := flags &^ s.Mask(); unknown != 0 {
+		return UnknownBitsError{Bits: unknown}
+	}
+	return nil
+}
+
+// Report describes flags as one "name: value" line per field,
+// followed by the unknown bits if any is set
+func (s BitSchema) Report(flags uint64) string {
+	var b strings.Builder
+	for _, f := range s {
+		value := (flags & f.mask()) >> f.Offset
+		if f.Kind == reflect.Bool {
+			fmt.Fprintf(&b, "%s: %t\n", f.Name, value == 1)
+		} else {
+			fmt.Fprintf(&b, "%s: %d\n", f.Name, value)
+		}
+	}
+	if unknown := flags &^ s.Mask(); unknown != 0 {
+		fmt.Fprintf(&b, "Unknown bits: %#x\n", unknown)
+	}
+	return b.String()
+}
+
+// field returns the struct field described by f checking its kind
+func (s BitSchema) field(st reflect.Value, f BitField) (reflect.Value, error) {
+	field := st.FieldByName(f.Name)
+	if !field.IsValid() {
+		return reflect.Value{}, fmt.Errorf("bit field %s not found in %s", f.Name, st.Type())
+	}
+	if field.Kind() != f.Kind {
+		return reflect.Value{}, fmt.Errorf("bit field %s is %s, schema expects %s", f.Name, field.Kind(), f.Kind)
+	}
+	return field, nil
+}
+
+// firmwareFeaturesSchema mirrors the FirmwareFeatures enum of the firmware.
+// A new firmware capability needs an entry here and a FirmwareFeatures field.
+var firmwareFeaturesSchema = BitSchema{
+	{Name: "RequireGetEntropyConfirm", Offset: 0, Width: 1, Kind: reflect.Bool},
+	{Name: "IsGetEntropyEnabled", Offset: 1, Width: 1, Kind: reflect.Bool},
+	{Name: "IsEmulator", Offset: 2, Width: 1, Kind: reflect.Bool},
+	{Name: "FirmwareFeaturesRdpLevel", Offset: 3, Width: 2, Kind: reflect.Uint8},
+}
+
 // FirmwareFeatures handle the features in firmware as a BitEncodedFlags implementation
 type FirmwareFeatures struct {
 	flags                    uint64
@@ -34,27 +185,19 @@ func NewFirmwareFeatures(flags uint64) BitEncodedFlags {
 // Marshal encode the FirmwareFeatures internal field in a uint64 value
 // return this number and keeps an internal copy
 func (ff *FirmwareFeatures) Marshal() (uint64, error) {
-	ff.flags = 0
-	bs := make([]byte, 8)
-	setBitInByte(&bs[7], ff.RequireGetEntropyConfirm, 0)
-	setBitInByte(&bs[7], ff.IsGetEntropyEnabled, 1)
-	setBitInByte(&bs[7], ff.IsEmulator, 2)
-	setBitInByte(&bs[7], ff.FirmwareFeaturesRdpLevel == 1 || ff.FirmwareFeaturesRdpLevel == 3, 3)
-	setBitInByte(&bs[7], ff.FirmwareFeaturesRdpLevel == 2 || ff.FirmwareFeaturesRdpLevel == 3, 4)
-	ff.flags = binary.BigEndian.Uint64(bs)
+	flags, err := firmwareFeaturesSchema.Encode(ff)
+	if err != nil {
+		return 0, err
+	}
+	ff.flags = flags
 	return ff.flags, nil
 }
 
-// Unmarshal fill all the struct fields based on the encoded info in the flags field
+// Unmarshal fill all the struct fields based on the encoded info in the flags field.
+// Returns an UnknownBitsError if the firmware sets bits this version does not know,
+// the known fields are filled anyway.
 func (ff *FirmwareFeatures) Unmarshal() error {
-	bs := make([]byte, 8)
-	binary.BigEndian.PutUint64(bs, ff.flags)
-	ff.RequireGetEntropyConfirm = bitStatusInByte(bs[7], 0)
-	ff.IsGetEntropyEnabled = bitStatusInByte(bs[7], 1)
-	ff.IsEmulator = bitStatusInByte(bs[7], 2)
-	setBitInByte(&ff.FirmwareFeaturesRdpLevel, bitStatusInByte(bs[7], 3), 0)
-	setBitInByte(&ff.FirmwareFeaturesRdpLevel, bitStatusInByte(bs[7], 4), 1)
-	return nil
+	return firmwareFeaturesSchema.Decode(ff.flags, ff)
 }
 
 // HasRdpMemProtectEnabled return true if rdp == true
@@ -62,6 +205,11 @@ func (ff FirmwareFeatures) HasRdpMemProtectEnabled() bool {
 	return ff.FirmwareFeaturesRdpLevel == 2
 }
 
+// Report returns a human readable description of every firmware feature
+func (ff FirmwareFeatures) Report() string {
+	return firmwareFeaturesSchema.Report(ff.flags)
+}
+
 // String allow pretty print in cli applications
 func (ff FirmwareFeatures) String() string {
 	b, err := json.Marshal(ff)
@@ -70,16 +218,3 @@ func (ff FirmwareFeatures) String() string {
 	}
 	return string(b)
 }
-
-func bitStatusInByte(data, bitPos uint8) bool {
-	return (data & (uint8)(1<<bitPos)) != 0
-}
-
-func setBitInByte(data *uint8, val bool, bitPos uint8) {
-	mask := (uint8)(1 << bitPos)
-	if val {
-		*data |= mask
-	} else {
-		*data &= mask ^ 255
-	}
-}
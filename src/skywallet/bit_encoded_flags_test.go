@@ -2,7 +2,9 @@ package skywallet
 
 import (
 	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	messages "github.com/skycoin/hardware-wallet-protob/go"
 	"github.com/stretchr/testify/suite"
@@ -47,3 +49,88 @@ func (suite *bitEncodedFlagsSuit) TestShouldHaveNtRdpEnabled() {
 	// NOTE: Assert
 	suite.False(ff.HasRdpMemProtectEnabled())
 }
+
+func (suite *bitEncodedFlagsSuit) TestFirmwareFeaturesSchemaIsValid() {
+	suite.NoError(firmwareFeaturesSchema.Validate())
+	suite.Equal(uint64(0x1f), firmwareFeaturesSchema.Mask())
+}
+
+func (suite *bitEncodedFlagsSuit) TestKnownBitsRoundTrip() {
+	roundTrip := func(flags uint64) bool {
+		flags &= firmwareFeaturesSchema.Mask()
+		ff := NewFirmwareFeatures(flags)
+		if err := ff.Unmarshal(); err != nil {
+			return false
+		}
+		f, err := ff.Marshal()
+		return err == nil && f == flags
+	}
+	suite.NoError(quick.Check(roundTrip, nil))
+}
+
+func (suite *bitEncodedFlagsSuit) TestUnknownBitsFail() {
+	unknownBits := func(flags uint64) bool {
+		unknown := flags &^ firmwareFeaturesSchema.Mask()
+		if unknown == 0 {
+			return true
+		}
+		ff := NewFirmwareFeatures(flags)
+		err := ff.Unmarshal()
+		f, _ := ff.Marshal()
+		return err == UnknownBitsError{Bits: unknown} && f == flags&firmwareFeaturesSchema.Mask()
+	}
+	suite.NoError(quick.Check(unknownBits, nil))
+}
+
+func (suite *bitEncodedFlagsSuit) TestReport() {
+	// NOTE: Giving
+	ff := FirmwareFeatures{flags: uint64(messages.FirmwareFeatures_IsEmulator) | uint64(messages.FirmwareFeatures_FirmwareFeatures_RdpMemProtect) | 1<<7}
+	// NOTE: When
+	report := ff.Report()
+	// NOTE: Assert
+	suite.Equal(`RequireGetEntropyConfirm: false
+IsGetEntropyEnabled: false
+IsEmulator: true
+FirmwareFeaturesRdpLevel: 2
+Unknown bits: 0x80
+`, report)
+}
+
+func (suite *bitEncodedFlagsSuit) TestSchemaValidate() {
+	tt := []struct {
+		name   string
+		schema BitSchema
+		err    string
+	}{
+		{
+			name:   "overlap",
+			schema: BitSchema{{Name: "A", Offset: 0, Width: 2, Kind: reflect.Uint8}, {Name: "B", Offset: 1, Width: 1, Kind: reflect.Bool}},
+			err:    "bit field B overlaps another field",
+		},
+		{
+			name:   "wide bool",
+			schema: BitSchema{{Name: "A", Offset: 0, Width: 2, Kind: reflect.Bool}},
+			err:    "bit field A is a bool and must be one bit wide",
+		},
+		{
+			name:   "out of range",
+			schema: BitSchema{{Name: "A", Offset: 63, Width: 2, Kind: reflect.Uint8}},
+			err:    "bit field A does not fit in 64 bits",
+		},
+		{
+			name:   "unsupported kind",
+			schema: BitSchema{{Name: "A", Offset: 0, Width: 8, Kind: reflect.String}},
+			err:    "bit field A has unsupported kind string",
+		},
+	}
+
+	for _, tc := range tt {
+		suite.EqualError(tc.schema.Validate(), tc.err, tc.name)
+	}
+}
+
+func (suite *bitEncodedFlagsSuit) TestEncodeValueOverflow() {
+	ff := FirmwareFeatures{FirmwareFeaturesRdpLevel: 4}
+	_, err := ff.Marshal()
+	suite.EqualError(err, "bit field FirmwareFeaturesRdpLevel value 4 does not fit in 2 bits")
+}
@@ -0,0 +1,281 @@
+package skywallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+)
+
+// txBatchSize is the number of inputs or outputs the firmware accepts in a single TxAck
+const txBatchSize = 8
+
+var (
+	// ErrInvalidVersion is returned if a version string is not major.minor.patch
+	ErrInvalidVersion = errors.New("version must be major.minor.patch")
+)
+
+// Version is a semantic version of the device firmware or bootloader
+type Version struct {
+	Major uint32
+	Minor uint32
+	Patch uint32
+	// Prerelease is the optional text after the patch number, i.e. rc1 in 1.8.0-rc1
+	Prerelease string
+}
+
+// ParseVersion parses a semantic version like 1.7.0, v1.8.0 or 1.8.0-rc1.
+// Build metadata following a + sign is ignored.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	var v Version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Prerelease = s[i+1:]
+		if v.Prerelease == "" {
+			return Version{}, ErrInvalidVersion
+		}
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, ErrInvalidVersion
+	}
+	numbers := make([]uint32, 3)
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return Version{}, ErrInvalidVersion
+		}
+		numbers[i] = uint32(n)
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+// String returns the version as major.minor.patch[-prerelease]
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower, equal or greater than o.
+// A prerelease is lower than the release it precedes, two prereleases of the
+// same release are compared as text.
+func (v Version) Compare(o Version) int {
+	for _, c := range [][2]uint32{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] < c[1] {
+			return -1
+		}
+		if c[0] > c[1] {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.Prerelease, o.Prerelease)
+}
+
+// AtLeast returns true if v is equal or greater than o
+func (v Version) AtLeast(o Version) bool {
+	return v.Compare(o) >= 0
+}
+
+// MarshalText encodes the version as its string representation
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText decodes a version from its string representation
+func (v *Version) UnmarshalText(text []byte) error {
+	parsed, err := ParseVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// UnsupportedError is returned if the device firmware does not support a feature.
+// No firmware feature bit announces bitcoin, it is reported once the device
+// rejects a bitcoin message it does not know.
+type UnsupportedError struct {
+	Feature         string
+	FirmwareVersion *Version
+}
+
+func (e UnsupportedError) Error() string {
+	if e.FirmwareVersion == nil {
+		return fmt.Sprintf("%s is not supported by the device firmware", e.Feature)
+	}
+	return fmt.Sprintf("%s is not supported by firmware version %s", e.Feature, e.FirmwareVersion)
+}
+
+// DeviceInfo merges the device Features with its decoded FirmwareFeatures
+// and parsed firmware and bootloader versions.
+// It has no SupportsBitcoin: no FirmwareFeatures bit nor firmware version announces
+// bitcoin, and probing with a bitcoin message may ask for the PIN. The bitcoin
+// methods return an UnsupportedError instead once the firmware rejects them.
+type DeviceInfo struct {
+	Vendor               string           `json:"vendor,omitempty"`
+	Model                string           `json:"model,omitempty"`
+	DeviceID             string           `json:"device_id,omitempty"`
+	Label                string           `json:"label,omitempty"`
+	Language             string           `json:"language,omitempty"`
+	BootloaderMode       bool             `json:"bootloader_mode"`
+	Initialized          bool             `json:"initialized"`
+	PinProtection        bool             `json:"pin_protection"`
+	PassphraseProtection bool             `json:"passphrase_protection"`
+	PinCached            bool             `json:"pin_cached"`
+	PassphraseCached     bool             `json:"passphrase_cached"`
+	NeedsBackup          bool             `json:"needs_backup"`
+	FirmwarePresent      bool             `json:"firmware_present"`
+	FirmwareVersion      *Version         `json:"firmware_version,omitempty"`
+	FirmwareVersionHead  string           `json:"firmware_version_head,omitempty"`
+	BootloaderVersion    *Version         `json:"bootloader_version,omitempty"`
+	BootloaderHash       string           `json:"bootloader_hash,omitempty"`
+	FirmwareFeatures     FirmwareFeatures `json:"firmware_features"`
+
+	// Features is the message the info was built from
+	Features *messages.Features `json:"-"`
+}
+
+// NewDeviceInfo builds a DeviceInfo from the Features message.
+// Running firmware reports its version in major_version, minor_version and
+// patch_version. In bootloader mode those fields hold the bootloader version
+// and fw_major, fw_minor and fw_patch the version of the installed firmware.
+// Versions the device does not report are left nil.
+func NewDeviceInfo(features *messages.Features) (*DeviceInfo, error) {
+	info := &DeviceInfo{
+		Vendor:               features.GetVendor(),
+		Model:                features.GetModel(),
+		DeviceID:             features.GetDeviceId(),
+		Label:                features.GetLabel(),
+		Language:             features.GetLanguage(),
+		BootloaderMode:       features.GetBootloaderMode(),
+		Initialized:          features.GetInitialized(),
+		PinProtection:        features.GetPinProtection(),
+		PassphraseProtection: features.GetPassphraseProtection(),
+		PinCached:            features.GetPinCached(),
+		PassphraseCached:     features.GetPassphraseCached(),
+		NeedsBackup:          features.GetNeedsBackup(),
+		FirmwarePresent:      features.GetFirmwarePresent(),
+		FirmwareVersionHead:  features.GetFwVersionHead(),
+		Features:             features,
+	}
+
+	var version *Version
+	if features.MajorVersion != nil {
+		version = &Version{
+			Major: features.GetMajorVersion(),
+			Minor: features.GetMinorVersion(),
+			Patch: features.GetPatchVersion(),
+		}
+	}
+	if !info.BootloaderMode {
+		info.FirmwareVersion = version
+	} else {
+		info.BootloaderVersion = version
+		if features.GetFwMajor() != 0 || features.GetFwMinor() != 0 || features.GetFwPatch() != 0 {
+			info.FirmwareVersion = &Version{
+				Major: features.GetFwMajor(),
+				Minor: features.GetFwMinor(),
+				Patch: features.GetFwPatch(),
+			}
+		}
+	}
+	if len(features.BootloaderHash) > 0 {
+		info.BootloaderHash = hex.EncodeToString(features.BootloaderHash)
+	}
+
+	if features.FirmwareFeatures != nil {
+		ff := FirmwareFeatures{flags: uint64(features.GetFirmwareFeatures())}
+		if err := ff.Unmarshal(); err != nil {
+			// firmware newer than this library may announce capabilities it does not know yet
+			if _, ok := err.(UnknownBitsError); !ok {
+				return nil, err
+			}
+			log.Warnf("firmware features: %v", err)
+		}
+		info.FirmwareFeatures = ff
+	}
+	return info, nil
+}
+
+// SupportsEntropy returns true if the firmware answers GetRawEntropy and GetMixedEntropy
+func (i DeviceInfo) SupportsEntropy() bool {
+	return i.FirmwareFeatures.IsGetEntropyEnabled
+}
+
+// SupportsLoadDevice returns true if the device accepts LoadDevice.
+// Debug firmware builds can not be told apart from release builds by their
+// features, so only the emulator is accepted.
+func (i DeviceInfo) SupportsLoadDevice() bool {
+	return i.FirmwareFeatures.IsEmulator
+}
+
+// MaxTxBatch returns the number of transaction inputs or outputs sent to the device in a single message
+func (i DeviceInfo) MaxTxBatch() int {
+	return txBatchSize
+}
+
+// RequireEntropy returns an UnsupportedError if the firmware does not support reading entropy
+func (i DeviceInfo) RequireEntropy() error {
+	if !i.SupportsEntropy() {
+		return UnsupportedError{Feature: "entropy", FirmwareVersion: i.FirmwareVersion}
+	}
+	return nil
+}
+
+// RequireLoadDevice returns an UnsupportedError if the device does not accept LoadDevice
+func (i DeviceInfo) RequireLoadDevice() error {
+	if !i.SupportsLoadDevice() {
+		return UnsupportedError{Feature: "load device", FirmwareVersion: i.FirmwareVersion}
+	}
+	return nil
+}
+
+// String allow pretty print in cli applications
+func (i DeviceInfo) String() string {
+	b, err := json.MarshalIndent(i, "", "    ")
+	if err != nil {
+		return "error rendering DeviceInfo " + err.Error()
+	}
+	return string(b)
+}
+
+// DeviceInfo asks the device its features and returns them as a DeviceInfo
+func (d *Device) DeviceInfo() (*DeviceInfo, error) {
+	features, err := d.features()
+	if err != nil {
+		return nil, err
+	}
+	return NewDeviceInfo(features)
+}
+
+// features asks the device its features
+func (d *Device) features() (*messages.Features, error) {
+	msg, err := d.GetFeatures()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFeaturesMsg(msg)
+}
@@ -0,0 +1,178 @@
+package skywallet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+type deviceInfoSuit struct {
+	suite.Suite
+}
+
+func TestDeviceInfoSuit(t *testing.T) {
+	suite.Run(t, new(deviceInfoSuit))
+}
+
+func (suite *deviceInfoSuit) TestParseVersion() {
+	tt := []struct {
+		version  string
+		expected Version
+		err      error
+	}{
+		{version: "1.7.0", expected: Version{Major: 1, Minor: 7}},
+		{version: "v1.8.2", expected: Version{Major: 1, Minor: 8, Patch: 2}},
+		{version: "1.8.0-rc1", expected: Version{Major: 1, Minor: 8, Prerelease: "rc1"}},
+		{version: "1.8.0+build5", expected: Version{Major: 1, Minor: 8}},
+		{version: "1.8", err: ErrInvalidVersion},
+		{version: "1.8.x", err: ErrInvalidVersion},
+		{version: "1.8.0-", err: ErrInvalidVersion},
+		{version: "", err: ErrInvalidVersion},
+	}
+
+	for _, tc := range tt {
+		v, err := ParseVersion(tc.version)
+		suite.Equal(tc.err, err, tc.version)
+		suite.Equal(tc.expected, v, tc.version)
+	}
+}
+
+func (suite *deviceInfoSuit) TestCompareVersion() {
+	tt := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.7.0", b: "1.7.0", expected: 0},
+		{a: "1.7.0", b: "1.8.0", expected: -1},
+		{a: "2.0.0", b: "1.9.9", expected: 1},
+		{a: "1.8.1", b: "1.8.0", expected: 1},
+		{a: "1.8.0-rc1", b: "1.8.0", expected: -1},
+		{a: "1.8.0-rc2", b: "1.8.0-rc1", expected: 1},
+		{a: "1.8.0-rc1", b: "1.7.9", expected: 1},
+	}
+
+	for _, tc := range tt {
+		a, err := ParseVersion(tc.a)
+		suite.Require().NoError(err)
+		b, err := ParseVersion(tc.b)
+		suite.Require().NoError(err)
+		suite.Equal(tc.expected, a.Compare(b), tc.a+" "+tc.b)
+		suite.Equal(tc.expected >= 0, a.AtLeast(b), tc.a+" "+tc.b)
+	}
+}
+
+func (suite *deviceInfoSuit) TestNewDeviceInfo() {
+	// NOTE: Giving
+	features := &messages.Features{
+		Vendor:           proto.String("Skycoin Foundation"),
+		DeviceId:         proto.String("ABCD"),
+		Label:            proto.String("treasury"),
+		Initialized:      proto.Bool(true),
+		BootloaderMode:   proto.Bool(true),
+		MajorVersion:     proto.Uint32(1),
+		MinorVersion:     proto.Uint32(2),
+		PatchVersion:     proto.Uint32(0),
+		FwMajor:          proto.Uint32(1),
+		FwMinor:          proto.Uint32(8),
+		FwPatch:          proto.Uint32(0),
+		BootloaderHash:   []byte{0xca, 0xfe},
+		FirmwareFeatures: proto.Uint32(uint32(messages.FirmwareFeatures_IsGetEntropyEnabled)),
+	}
+
+	// NOTE: When
+	info, err := NewDeviceInfo(features)
+
+	// NOTE: Assert
+	suite.Require().NoError(err)
+	suite.Equal("ABCD", info.DeviceID)
+	suite.Equal("treasury", info.Label)
+	suite.True(info.Initialized)
+	suite.True(info.BootloaderMode)
+	suite.Equal(&Version{Major: 1, Minor: 8}, info.FirmwareVersion)
+	suite.Equal(&Version{Major: 1, Minor: 2}, info.BootloaderVersion)
+	suite.Equal("cafe", info.BootloaderHash)
+	suite.True(info.FirmwareFeatures.IsGetEntropyEnabled)
+	suite.Equal(features, info.Features)
+
+	b, err := json.Marshal(info)
+	suite.Require().NoError(err)
+	var decoded DeviceInfo
+	suite.Require().NoError(json.Unmarshal(b, &decoded))
+	suite.Equal(info.FirmwareVersion, decoded.FirmwareVersion)
+}
+
+func (suite *deviceInfoSuit) TestCapabilities() {
+	tt := []struct {
+		name     string
+		features *messages.Features
+		entropy  bool
+	}{
+		{
+			name:     "old firmware",
+			features: &messages.Features{MajorVersion: proto.Uint32(1), MinorVersion: proto.Uint32(7), PatchVersion: proto.Uint32(0)},
+		},
+		{
+			name: "firmware with entropy",
+			features: &messages.Features{
+				MajorVersion:     proto.Uint32(1),
+				MinorVersion:     proto.Uint32(8),
+				PatchVersion:     proto.Uint32(0),
+				FirmwareFeatures: proto.Uint32(uint32(messages.FirmwareFeatures_IsGetEntropyEnabled)),
+			},
+			entropy: true,
+		},
+		{
+			name: "bootloader without firmware",
+			features: &messages.Features{
+				BootloaderMode: proto.Bool(true),
+				MajorVersion:   proto.Uint32(1),
+				MinorVersion:   proto.Uint32(8),
+				PatchVersion:   proto.Uint32(0),
+				FwMajor:        proto.Uint32(0),
+				FwMinor:        proto.Uint32(0),
+				FwPatch:        proto.Uint32(0),
+			},
+		},
+		{
+			name:     "emulator",
+			features: &messages.Features{FirmwareFeatures: proto.Uint32(uint32(messages.FirmwareFeatures_IsEmulator))},
+		},
+	}
+
+	for _, tc := range tt {
+		info, err := NewDeviceInfo(tc.features)
+		suite.Require().NoError(err)
+		suite.Equal(tc.entropy, info.SupportsEntropy(), tc.name)
+		suite.Equal(txBatchSize, info.MaxTxBatch(), tc.name)
+	}
+
+	info, err := NewDeviceInfo(tt[0].features)
+	suite.Require().NoError(err)
+	suite.EqualError(info.RequireEntropy(), "entropy is not supported by firmware version 1.7.0")
+}
+
+func (suite *deviceInfoSuit) TestDeviceInfo() {
+	// NOTE: Giving
+	data, err := proto.Marshal(&messages.Features{DeviceId: proto.String("ABCD")})
+	suite.Require().NoError(err)
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: data}, nil)
+	device := getMockDevice(driverMock)
+
+	// NOTE: When
+	info, err := device.DeviceInfo()
+
+	// NOTE: Assert
+	suite.NoError(err)
+	suite.Equal("ABCD", info.DeviceID)
+	suite.Nil(info.FirmwareVersion)
+	driverMock.AssertCalled(suite.T(), "SendToDevice", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,76 @@
+package skywallet
+
+import (
+	"fmt"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+)
+
+// CheckStatus is the outcome of a diagnostic check
+type CheckStatus int
+
+const (
+	// CheckOK the check passed
+	CheckOK CheckStatus = iota
+	// CheckWarning the check found something that may prevent using the device
+	CheckWarning
+	// CheckFailed the check found something that prevents using the device
+	CheckFailed
+)
+
+func (s CheckStatus) String() string {
+	switch s {
+	case CheckOK:
+		return "ok"
+	case CheckWarning:
+		return "warning"
+	case CheckFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("CheckStatus(%d)", int(s))
+	}
+}
+
+// Check is the result of a diagnostic check
+type Check struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+	// Remediation lists the steps fixing a check that did not pass
+	Remediation []string
+}
+
+// Diagnose checks whether the host can talk to a device over usb: libusb initializes and,
+// on linux, a device is connected, the user may open its device nodes, the udev rule is
+// installed and neither a kernel driver nor another process holds its interface
+func Diagnose() []Check {
+	checks := []Check{diagnoseLibUSB()}
+	return append(checks, diagnoseSystem()...)
+}
+
+func diagnoseLibUSB() Check {
+	b, err := usb.InitLibUSB(true, allowCancel(), detachKernelDriver())
+	if err != nil {
+		return Check{
+			Name:   "libusb",
+			Status: CheckFailed,
+			Detail: err.Error(),
+			Remediation: []string{
+				"install libusb 1.0, i.e. sudo apt install libusb-1.0-0 or brew install libusb",
+				"usb devices are not available in most containers and CI runners, run on the host",
+			},
+		}
+	}
+	b.Close()
+	return Check{Name: "libusb", Status: CheckOK, Detail: "initialized"}
+}
+
+// Failed tells whether any check failed
+func Failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == CheckFailed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+package skywallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// linuxSystem holds the paths the linux checks read, so tests can use a fake tree
+type linuxSystem struct {
+	// sysfs lists the usb devices and their interfaces
+	sysfs string
+	dev   string
+	proc  string
+	// rulesDirs are searched for a udev rule matching the vendor id
+	rulesDirs []string
+}
+
+var defaultLinuxSystem = linuxSystem{
+	sysfs:     "/sys/bus/usb/devices",
+	dev:       "/dev",
+	proc:      "/proc",
+	rulesDirs: []string{"/etc/udev/rules.d", "/run/udev/rules.d", "/lib/udev/rules.d", "/usr/lib/udev/rules.d"},
+}
+
+// udevRuleRemediation installs the udev rule shipped in the repository
+var udevRuleRemediation = []string{
+	"sudo cp udev/51-skywallet.rules /etc/udev/rules.d/",
+	"sudo udevadm control --reload-rules && sudo udevadm trigger",
+	"unplug and replug the device",
+}
+
+func diagnoseSystem() []Check {
+	return defaultLinuxSystem.diagnose()
+}
+
+// usbDevice is a device with the skycoin vendor id found in sysfs
+type usbDevice struct {
+	name      string
+	product   string
+	busnum    int
+	devnum    int
+	node      string
+	ifaces    []string
+	hidrawDev []string
+}
+
+func (s linuxSystem) diagnose() []Check {
+	checks := []Check{s.checkUdevRule()}
+
+	devices, err := s.devices()
+	if err != nil {
+		return append(checks, Check{
+			Name:        "device",
+			Status:      CheckFailed,
+			Detail:      err.Error(),
+			Remediation: []string{"usb devices are not available in most containers and CI runners, run on the host"},
+		})
+	}
+	if len(devices) == 0 {
+		return append(checks, Check{
+			Name:   "device",
+			Status: CheckFailed,
+			Detail: fmt.Sprintf("no usb device with vendor id %04x", SkycoinVendorID),
+			Remediation: []string{
+				"connect the device, lsusb | grep 313a must list it",
+				"try another cable or usb port, some cables only charge",
+				"sudo dmesg | tail shows the kernel messages after plugging it",
+			},
+		})
+	}
+
+	holders := s.holders()
+	for _, d := range devices {
+		checks = append(checks, Check{
+			Name:   "device " + d.name,
+			Status: CheckOK,
+			Detail: fmt.Sprintf("%04x:%s on bus %03d device %03d", SkycoinVendorID, d.product, d.busnum, d.devnum),
+		})
+		checks = append(checks, s.checkNode(d.node, CheckFailed))
+		for _, hidraw := range d.hidrawDev {
+			// libusb is used on linux, the hidraw nodes only matter to other programs
+			checks = append(checks, s.checkNode(hidraw, CheckWarning))
+		}
+		for _, iface := range d.ifaces {
+			checks = append(checks, s.checkDriver(iface))
+		}
+		checks = append(checks, checkHolders(d, holders))
+	}
+	return checks
+}
+
+// checkUdevRule looks for a udev rule matching the vendor id
+func (s linuxSystem) checkUdevRule() Check {
+	vendorID := fmt.Sprintf("%04x", SkycoinVendorID)
+	for _, dir := range s.rulesDirs {
+		rules, _ := filepath.Glob(filepath.Join(dir, "*.rules"))
+		for _, rule := range rules {
+			content, err := os.ReadFile(rule)
+			if err == nil && strings.Contains(strings.ToLower(string(content)), vendorID) {
+				return Check{Name: "udev rule", Status: CheckOK, Detail: rule}
+			}
+		}
+	}
+	return Check{
+		Name:        "udev rule",
+		Status:      CheckWarning,
+		Detail:      fmt.Sprintf("no rule for vendor id %s in %s", vendorID, strings.Join(s.rulesDirs, ", ")),
+		Remediation: udevRuleRemediation,
+	}
+}
+
+// devices returns the devices with the skycoin vendor id
+func (s linuxSystem) devices() ([]usbDevice, error) {
+	entries, err := os.ReadDir(s.sysfs)
+	if err != nil {
+		return nil, fmt.Errorf("listing usb devices: %v", err)
+	}
+
+	var devices []usbDevice
+	for _, entry := range entries {
+		name := entry.Name()
+		// interfaces are named after their device, i.e. 1-1.5:1.0
+		if strings.Contains(name, ":") {
+			continue
+		}
+		if s.attr(name, "idVendor") != fmt.Sprintf("%04x", SkycoinVendorID) {
+			continue
+		}
+
+		d := usbDevice{name: name, product: s.attr(name, "idProduct")}
+		d.busnum, _ = strconv.Atoi(s.attr(name, "busnum"))
+		d.devnum, _ = strconv.Atoi(s.attr(name, "devnum"))
+		d.node = filepath.Join(s.dev, "bus", "usb", fmt.Sprintf("%03d", d.busnum), fmt.Sprintf("%03d", d.devnum))
+
+		ifaces, _ := filepath.Glob(filepath.Join(s.sysfs, name+":*"))
+		for _, iface := range ifaces {
+			d.ifaces = append(d.ifaces, filepath.Base(iface))
+			// the hidraw node is below the hid device of the interface
+			hidraws, _ := filepath.Glob(filepath.Join(iface, "*", "hidraw", "hidraw*"))
+			for _, hidraw := range hidraws {
+				d.hidrawDev = append(d.hidrawDev, filepath.Join(s.dev, filepath.Base(hidraw)))
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func (s linuxSystem) attr(device, name string) string {
+	value, err := os.ReadFile(filepath.Join(s.sysfs, device, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(value))
+}
+
+// checkNode checks the user may open the device node at path, reporting status if not
+func (s linuxSystem) checkNode(path string, status CheckStatus) Check {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Check{
+			Name:        path,
+			Status:      status,
+			Detail:      err.Error(),
+			Remediation: []string{"sudo udevadm trigger", "unplug and replug the device"},
+		}
+	}
+
+	group, gid := nodeGroup(info)
+	detail := fmt.Sprintf("%s owned by group %s", info.Mode(), group)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err == nil {
+		f.Close()
+		return Check{Name: path, Status: CheckOK, Detail: detail}
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		return Check{Name: path, Status: status, Detail: fmt.Sprintf("%s: %v", detail, err)}
+	}
+
+	remediation := udevRuleRemediation
+	// a node writable by its group only needs the user in the group
+	if gid != 0 && info.Mode().Perm()&0060 == 0060 && !inGroup(gid) {
+		remediation = append([]string{fmt.Sprintf("sudo usermod -aG %s $USER, then log out and back in", group)}, remediation...)
+	}
+	return Check{
+		Name:        path,
+		Status:      status,
+		Detail:      fmt.Sprintf("%s: permission denied for %s", detail, currentUser()),
+		Remediation: remediation,
+	}
+}
+
+// checkDriver reports the kernel driver bound to the interface iface
+func (s linuxSystem) checkDriver(iface string) Check {
+	name := "interface " + iface
+	link, err := os.Readlink(filepath.Join(s.sysfs, iface, "driver"))
+	if err != nil {
+		return Check{Name: name, Status: CheckOK, Detail: "no kernel driver bound"}
+	}
+
+	switch driver := filepath.Base(link); driver {
+	case "usbfs":
+		return Check{
+			Name:        name,
+			Status:      CheckWarning,
+			Detail:      "claimed by a program through libusb",
+			Remediation: []string{"close the programs holding the device"},
+		}
+	case "usbhid":
+		return Check{
+			Name:   name,
+			Status: CheckWarning,
+			Detail: "the usbhid kernel driver is bound, detaching it needs privileges",
+			Remediation: []string{
+				fmt.Sprintf("echo %s | sudo tee /sys/bus/usb/drivers/usbhid/unbind", iface),
+				"to unbind it on every connection install the rule in README-USB-FIX.md",
+			},
+		}
+	default:
+		return Check{
+			Name:        name,
+			Status:      CheckWarning,
+			Detail:      fmt.Sprintf("the %s kernel driver is bound", driver),
+			Remediation: []string{fmt.Sprintf("echo %s | sudo tee /sys/bus/usb/drivers/%s/unbind", iface, driver)},
+		}
+	}
+}
+
+// process is a process holding a device node open
+type process struct {
+	pid  int
+	name string
+}
+
+// holders returns the processes of other pids holding each open file, as far as the user may see them
+func (s linuxSystem) holders() map[string][]process {
+	holders := make(map[string][]process)
+	entries, err := os.ReadDir(s.proc)
+	if err != nil {
+		return holders
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(s.proc, entry.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		comm, _ := os.ReadFile(filepath.Join(s.proc, entry.Name(), "comm"))
+		p := process{pid: pid, name: strings.TrimSpace(string(comm))}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(s.proc, entry.Name(), "fd", fd.Name()))
+			if err == nil {
+				holders[target] = append(holders[target], p)
+			}
+		}
+	}
+	return holders
+}
+
+// checkHolders reports the processes holding the nodes of d
+func checkHolders(d usbDevice, holders map[string][]process) Check {
+	name := "device " + d.name + " holders"
+	var held []string
+	var kill []string
+	for _, node := range append([]string{d.node}, d.hidrawDev...) {
+		for _, p := range holders[node] {
+			held = append(held, fmt.Sprintf("%s by pid %d (%s)", node, p.pid, p.name))
+			kill = append(kill, strconv.Itoa(p.pid))
+		}
+	}
+	if len(held) == 0 {
+		detail := "no other process has the device open"
+		if os.Geteuid() != 0 {
+			detail = "no other process of this user has the device open, run as root to check the others"
+		}
+		return Check{Name: name, Status: CheckOK, Detail: detail}
+	}
+
+	sort.Strings(held)
+	return Check{
+		Name:   name,
+		Status: CheckFailed,
+		Detail: "held open " + strings.Join(held, ", "),
+		Remediation: []string{
+			"close the program holding the device, i.e. a wallet application or another skycoin-hw-cli",
+			"or stop it with kill " + strings.Join(kill, " "),
+		},
+	}
+}
+
+// nodeGroup returns the name and id of the group owning a device node
+func nodeGroup(info os.FileInfo) (string, uint32) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "unknown", 0
+	}
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	if group, err := user.LookupGroupId(gid); err == nil {
+		return group.Name, stat.Gid
+	}
+	return gid, stat.Gid
+}
+
+// inGroup tells whether the process is in the group gid
+func inGroup(gid uint32) bool {
+	if uint32(os.Getegid()) == gid {
+		return true
+	}
+	groups, err := os.Getgroups()
+	if err != nil {
+		return false
+	}
+	for _, g := range groups {
+		if uint32(g) == gid {
+			return true
+		}
+	}
+	return false
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return "user " + u.Username
+	}
+	return fmt.Sprintf("uid %d", os.Geteuid())
+}
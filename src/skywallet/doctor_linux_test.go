@@ -0,0 +1,85 @@
+package skywallet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinuxSystemDiagnose(t *testing.T) {
+	root := t.TempDir()
+	// mkfile writes content to the file at path below root
+	mkfile := func(path, content string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	symlink := func(target, path string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.Symlink(target, path))
+	}
+
+	mkfile("sys/1-1.5/idVendor", "313a\n")
+	mkfile("sys/1-1.5/idProduct", "0001\n")
+	mkfile("sys/1-1.5/busnum", "1\n")
+	mkfile("sys/1-1.5/devnum", "5\n")
+	symlink("../../../bus/usb/drivers/usbhid", "sys/1-1.5:1.0/driver")
+	mkfile("sys/1-1.5:1.0/0003:313A:0001.0001/hidraw/hidraw0/dev", "241:0\n")
+	mkfile("sys/2-1/idVendor", "046d\n")
+	mkfile("dev/bus/usb/001/005", "")
+	mkfile("proc/4242/comm", "electron\n")
+	symlink(filepath.Join(root, "dev/bus/usb/001/005"), "proc/4242/fd/3")
+	symlink("/dev/null", "proc/4242/fd/0")
+
+	s := linuxSystem{
+		sysfs:     filepath.Join(root, "sys"),
+		dev:       filepath.Join(root, "dev"),
+		proc:      filepath.Join(root, "proc"),
+		rulesDirs: []string{filepath.Join(root, "rules.d")},
+	}
+
+	checks := s.diagnose()
+	byName := make(map[string]Check)
+	for _, c := range checks {
+		byName[strings.TrimPrefix(c.Name, root)] = c
+	}
+
+	require.Equal(t, CheckWarning, byName["udev rule"].Status)
+	require.Equal(t, udevRuleRemediation, byName["udev rule"].Remediation)
+	require.Equal(t, CheckOK, byName["device 1-1.5"].Status)
+	require.Equal(t, "313a:0001 on bus 001 device 005", byName["device 1-1.5"].Detail)
+	require.Equal(t, CheckOK, byName["/dev/bus/usb/001/005"].Status)
+	// the hidraw node is missing
+	require.Equal(t, CheckWarning, byName["/dev/hidraw0"].Status)
+	require.Equal(t, CheckWarning, byName["interface 1-1.5:1.0"].Status)
+	require.Contains(t, byName["interface 1-1.5:1.0"].Remediation, "echo 1-1.5:1.0 | sudo tee /sys/bus/usb/drivers/usbhid/unbind")
+	holders := byName["device 1-1.5 holders"]
+	require.Equal(t, CheckFailed, holders.Status)
+	require.Contains(t, holders.Detail, "by pid 4242 (electron)")
+	require.True(t, Failed(checks))
+	require.NotContains(t, byName, "device 2-1")
+
+	// the rule is found by vendor id whatever the case
+	mkfile("rules.d/51-skywallet.rules", `SUBSYSTEM=="usb", ATTR{idVendor}=="313A", MODE="0666"`)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "proc/4242")))
+	checks = s.diagnose()
+	require.Equal(t, CheckOK, checks[0].Status)
+	require.Equal(t, filepath.Join(root, "rules.d/51-skywallet.rules"), checks[0].Detail)
+	require.False(t, Failed(checks))
+}
+
+func TestLinuxSystemNoDevice(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys"), 0755))
+	s := linuxSystem{sysfs: filepath.Join(root, "sys")}
+
+	checks := s.diagnose()
+	require.Len(t, checks, 2)
+	require.Equal(t, "device", checks[1].Name)
+	require.Equal(t, CheckFailed, checks[1].Status)
+	require.Equal(t, "no usb device with vendor id 313a", checks[1].Detail)
+}
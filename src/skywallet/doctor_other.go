@@ -0,0 +1,8 @@
+//go:build !linux
+
+package skywallet
+
+// diagnoseSystem has no check beyond libusb outside linux, the device nodes are not restricted
+func diagnoseSystem() []Check {
+	return nil
+}
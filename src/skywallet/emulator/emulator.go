@@ -0,0 +1,276 @@
+// Package emulator runs SkyWallet emulators for tests.
+//
+// Each emulator is started in its own temporary directory on a free udp port, passed
+// to it through the arguments or environment holding PortPlaceholder, so tests using
+// different emulators can call t.Parallel. Its output is written to the test log and
+// it is stopped when the test ends:
+//
+//	func TestSomething(t *testing.T) {
+//		t.Parallel()
+//		e := emulator.Start(t, emulator.Config{
+//			Mnemonic: "cloud flower upset remain green metal below cup stem infant art thank",
+//		})
+//		msg, err := e.Device.GetFeatures()
+//		...
+//	}
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+const (
+	// BinaryEnv is the environment variable holding the emulator binary used if Config.Binary is empty
+	BinaryEnv = "SKYWALLET_EMULATOR"
+	// PortPlaceholder is replaced by the emulator udp port in Config.Args and Config.Env
+	PortPlaceholder = "{port}"
+
+	defaultStartTimeout = 30 * time.Second
+	pingTimeout         = time.Second
+	pingInterval        = 100 * time.Millisecond
+)
+
+// defaultPort is held by the emulator listening on skywallet.EmulatorPort,
+// so emulators that can not be given another port run one at a time
+var defaultPort sync.Mutex
+
+// Config describes how to start an emulator and the seed it is loaded with
+type Config struct {
+	// Binary is the emulator executable, the BinaryEnv environment variable if empty
+	Binary string
+	// Args and Env are passed to the emulator with PortPlaceholder replaced by its port.
+	// If neither holds the placeholder the emulator is assumed to listen on
+	// skywallet.EmulatorPort and runs once no other such emulator is running.
+	Args []string
+	Env  []string
+	// StartTimeout is the time to wait for the emulator to answer a ping, 30s if zero
+	StartTimeout time.Duration
+
+	// Mnemonic is loaded with LoadDevice after the emulator is wiped.
+	// The emulator is left without seed if empty.
+	Mnemonic             string
+	Pin                  string
+	PassphraseProtection bool
+	Label                string
+}
+
+// Emulator is a running emulator
+type Emulator struct {
+	// Port is the udp port the emulator listens on
+	Port int
+	// Device communicates with the emulator, pressing the right button on button requests
+	Device *skywallet.Device
+
+	cmd    *exec.Cmd
+	exited chan struct{}
+	err    error
+	output *logWriter
+}
+
+// Start starts an emulator, waits until it answers a ping, wipes it and loads
+// config.Mnemonic if set. The test fails if any step fails and is skipped if
+// no emulator binary is configured. The emulator is stopped when the test ends.
+func Start(t testing.TB, config Config) *Emulator {
+	t.Helper()
+
+	binary := config.Binary
+	if binary == "" {
+		binary = os.Getenv(BinaryEnv)
+	}
+	if binary == "" {
+		t.Skipf("no emulator binary, set %s", BinaryEnv)
+	}
+
+	port := skywallet.EmulatorPort
+	if hasPlaceholder(config.Args) || hasPlaceholder(config.Env) {
+		var err error
+		if port, err = freePort(); err != nil {
+			t.Fatalf("emulator: no free port: %v", err)
+		}
+	} else {
+		defaultPort.Lock()
+		t.Cleanup(defaultPort.Unlock)
+	}
+
+	e := &Emulator{
+		Port:   port,
+		cmd:    exec.Command(binary, expand(config.Args, port)...),
+		exited: make(chan struct{}),
+		output: &logWriter{t: t, prefix: fmt.Sprintf("emulator %d: ", port)},
+	}
+	// every emulator keeps its flash storage in its own directory
+	e.cmd.Dir = t.TempDir()
+	e.cmd.Env = append(os.Environ(), expand(config.Env, port)...)
+	e.cmd.Stdout = e.output
+	e.cmd.Stderr = e.output
+
+	if err := e.cmd.Start(); err != nil {
+		t.Fatalf("emulator: %v", err)
+	}
+	go func() {
+		e.err = e.cmd.Wait()
+		close(e.exited)
+	}()
+	t.Cleanup(e.stop)
+
+	timeout := config.StartTimeout
+	if timeout == 0 {
+		timeout = defaultStartTimeout
+	}
+	device, err := e.waitReady(timeout)
+	if err != nil {
+		t.Fatalf("emulator %d: %v", port, err)
+	}
+	e.Device = device
+
+	if err := e.Device.SetAutoPressButton(true, skywallet.ButtonRight); err != nil {
+		t.Fatalf("emulator %d: %v", port, err)
+	}
+	if err := e.succeed(e.Device.Wipe()); err != nil {
+		t.Fatalf("emulator %d: wipe: %v", port, err)
+	}
+	if config.Mnemonic != "" {
+		if err := e.succeed(e.Device.LoadDevice(skywallet.LoadDeviceOptions{
+			Mnemonic:             config.Mnemonic,
+			Pin:                  config.Pin,
+			PassphraseProtection: config.PassphraseProtection,
+			Label:                config.Label,
+		})); err != nil {
+			t.Fatalf("emulator %d: load device: %v", port, err)
+		}
+	}
+
+	return e
+}
+
+// waitReady pings the emulator until it answers or timeout elapses
+func (e *Emulator) waitReady(timeout time.Duration) (*skywallet.Device, error) {
+	driver, err := skywallet.NewEmulatorDriver(e.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		device := skywallet.NewDeviceWithDriver(driver)
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		_, err := device.Ping(ctx, "emulator", skywallet.PingOptions{})
+		cancel()
+		if err == nil {
+			return device, nil
+		}
+		// unblock the read of a ping left without answer
+		device.Disconnect()
+
+		select {
+		case <-e.exited:
+			return nil, fmt.Errorf("exited before answering: %v", e.err)
+		case <-time.After(pingInterval):
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("no answer within %s: %v", timeout, err)
+		}
+	}
+}
+
+// succeed answers the button requests following msg and fails unless the emulator answers with Success
+func (e *Emulator) succeed(msg wire.Message, err error) error {
+	for err == nil && msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
+		msg, err = e.Device.ButtonAck()
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Kind != uint16(messages.MessageType_MessageType_Success) {
+		answer, err := skywallet.DecodeSuccessOrFailMsg(msg)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("unexpected answer: %s", answer)
+	}
+	return nil
+}
+
+// stop kills the emulator and waits for it to exit, so its output is logged before the test ends
+func (e *Emulator) stop() {
+	if e.Device != nil {
+		e.Device.Close()
+	}
+	select {
+	case <-e.exited:
+	default:
+		e.cmd.Process.Kill()
+		<-e.exited
+	}
+	e.output.flush()
+}
+
+// freePort returns an udp port no socket is bound to
+func freePort() (int, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port, nil
+}
+
+func hasPlaceholder(values []string) bool {
+	for _, v := range values {
+		if strings.Contains(v, PortPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand replaces PortPlaceholder by port in values
+func expand(values []string, port int) []string {
+	expanded := make([]string, len(values))
+	for i, v := range values {
+		expanded[i] = strings.ReplaceAll(v, PortPlaceholder, strconv.Itoa(port))
+	}
+	return expanded
+}
+
+// logWriter writes each line of the emulator output to the test log
+type logWriter struct {
+	t      testing.TB
+	prefix string
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.t.Logf("%s%s", w.prefix, w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush logs the last line if it lacks a newline
+func (w *logWriter) flush() {
+	if len(w.buf) > 0 {
+		w.t.Logf("%s%s", w.prefix, w.buf)
+		w.buf = nil
+	}
+}
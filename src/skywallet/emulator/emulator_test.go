@@ -0,0 +1,105 @@
+package emulator
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+const fakePortEnv = "FAKE_EMULATOR_PORT"
+
+// packetConn reads and answers the datagrams of the last peer
+type packetConn struct {
+	conn net.PacketConn
+	peer net.Addr
+}
+
+func (c *packetConn) Read(p []byte) (int, error) {
+	n, peer, err := c.conn.ReadFrom(p)
+	c.peer = peer
+	return n, err
+}
+
+func (c *packetConn) Write(p []byte) (int, error) {
+	return c.conn.WriteTo(p, c.peer)
+}
+
+// TestFakeEmulator is run by the tests as an emulator answering the
+// messages sent by Start and GetFeatures, it does nothing otherwise
+func TestFakeEmulator(t *testing.T) {
+	port := os.Getenv(fakePortEnv)
+	if port == "" {
+		return
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+	os.Stdout.WriteString("fake emulator listening on " + port + "\n")
+
+	c := &packetConn{conn: conn}
+	// the firmware features flag the emulator
+	features := &messages.Features{FirmwareFeatures: proto.Uint32(1 << 2)}
+	for {
+		msg, err := wire.ReadFrom(c)
+		require.NoError(t, err)
+		m, err := skywallet.Decode(*msg)
+		require.NoError(t, err)
+
+		var answer proto.Message = &messages.Success{}
+		switch m := m.(type) {
+		case *messages.Ping:
+			answer = &messages.Success{Message: m.Message}
+		case *messages.WipeDevice:
+			features.Initialized, features.Label = nil, nil
+		case *messages.LoadDevice:
+			features.Initialized, features.Label = proto.Bool(true), m.Label
+		case *messages.GetFeatures:
+			answer = features
+		default:
+			answer = &messages.Failure{Message: proto.String("unexpected message")}
+		}
+		reply, err := skywallet.Encode(answer)
+		require.NoError(t, err)
+		_, err = reply.WriteTo(c)
+		require.NoError(t, err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		label := "emulator" + strconv.Itoa(i)
+		t.Run(label, func(t *testing.T) {
+			t.Parallel()
+
+			e := Start(t, Config{
+				Binary:   os.Args[0],
+				Args:     []string{"-test.run=^TestFakeEmulator$"},
+				Env:      []string{fakePortEnv + "=" + PortPlaceholder},
+				Mnemonic: "cloud flower upset remain green metal below cup stem infant art thank",
+				Label:    label,
+			})
+			require.NotEqual(t, skywallet.EmulatorPort, e.Port)
+
+			msg, err := e.Device.GetFeatures()
+			require.NoError(t, err)
+			features, err := skywallet.DecodeFeaturesMsg(msg)
+			require.NoError(t, err)
+			require.True(t, features.GetInitialized())
+			require.Equal(t, label, features.GetLabel())
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	require.True(t, hasPlaceholder([]string{"-v", "PORT={port}"}))
+	require.False(t, hasPlaceholder([]string{"-v"}))
+	require.Equal(t, []string{"-v", "PORT=21400"}, expand([]string{"-v", "PORT={port}"}, 21400))
+}
@@ -0,0 +1,210 @@
+package skywallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// EntropySource supplies the host entropy sent to the device when it asks for it,
+// i.e. to mix it with its own entropy while generating a seed
+type EntropySource interface {
+	// Name identifies the source in the logs
+	Name() string
+	// Entropy returns n bytes of entropy
+	Entropy(n int) ([]byte, error)
+}
+
+// DefaultEntropySource is used by devices without an EntropySource
+var DefaultEntropySource EntropySource = RandEntropy{}
+
+// RandEntropy reads entropy from crypto/rand
+type RandEntropy struct{}
+
+// Name identifies the source in the logs
+func (RandEntropy) Name() string {
+	return "crypto/rand"
+}
+
+// Entropy returns n bytes read from crypto/rand
+func (RandEntropy) Entropy(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// FileEntropy reads entropy from a file, i.e. a hardware random number generator such as /dev/hwrng
+type FileEntropy struct {
+	Path string
+}
+
+// Name identifies the source in the logs
+func (e FileEntropy) Name() string {
+	return e.Path
+}
+
+// Entropy returns the next n bytes of the file
+func (e FileEntropy) Entropy(n int) ([]byte, error) {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return nil, fmt.Errorf("reading entropy from %s: %v", e.Path, err)
+	}
+	return buf, nil
+}
+
+// FixedEntropy returns the same bytes every time, to reproduce seed generation in tests.
+// It must not be used to generate real seeds.
+type FixedEntropy []byte
+
+// Name identifies the source in the logs
+func (FixedEntropy) Name() string {
+	return "fixed"
+}
+
+// Entropy returns the first n bytes
+func (e FixedEntropy) Entropy(n int) ([]byte, error) {
+	if len(e) < n {
+		return nil, fmt.Errorf("fixed entropy has %d bytes, %d required", len(e), n)
+	}
+	return append([]byte{}, e[:n]...), nil
+}
+
+// DiceEntropy derives entropy from dice rolls entered by the user
+type DiceEntropy struct {
+	// Sides of the dice, from 2 to 9. 6 if zero.
+	Sides int
+	// ReadRolls is called with the number of rolls still needed and returns the
+	// entered rolls as digits, spaces are ignored. It is called until enough
+	// rolls are entered.
+	ReadRolls func(needed int) (string, error)
+}
+
+// Name identifies the source in the logs
+func (e DiceEntropy) Name() string {
+	return fmt.Sprintf("d%d dice", e.sides())
+}
+
+func (e DiceEntropy) sides() int {
+	if e.Sides == 0 {
+		return 6
+	}
+	return e.Sides
+}
+
+// RollsNeeded returns the number of rolls holding at least n bytes of entropy
+func (e DiceEntropy) RollsNeeded(n int) int {
+	return int(math.Ceil(float64(8*n) / math.Log2(float64(e.sides()))))
+}
+
+// Entropy reads enough rolls for n bytes of entropy and returns the SHA-256
+// of the rolls, extended with a counter if more than 32 bytes are needed
+func (e DiceEntropy) Entropy(n int) ([]byte, error) {
+	if e.sides() < 2 || e.sides() > 9 {
+		return nil, fmt.Errorf("dice must have from 2 to 9 sides, not %d", e.sides())
+	}
+	if e.ReadRolls == nil {
+		return nil, errors.New("no dice rolls reader")
+	}
+
+	var rolls []byte
+	for needed := e.RollsNeeded(n); len(rolls) < needed; {
+		entered, err := e.ReadRolls(needed - len(rolls))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range strings.Join(strings.Fields(entered), "") {
+			if r < '1' || r > rune('0'+e.sides()) {
+				return nil, fmt.Errorf("invalid roll %q, rolls go from 1 to %d", r, e.sides())
+			}
+			rolls = append(rolls, byte(r))
+		}
+	}
+
+	var buf []byte
+	for counter := uint32(0); len(buf) < n; counter++ {
+		h := sha256.New()
+		h.Write(rolls)
+		if counter > 0 {
+			binary.Write(h, binary.BigEndian, counter)
+		}
+		buf = h.Sum(buf)
+	}
+	return buf[:n], nil
+}
+
+// entropySourceSetter is implemented by the drivers answering EntropyRequest messages
+type entropySourceSetter interface {
+	SetEntropySource(source EntropySource)
+}
+
+// entropySourceHolder keeps the EntropySource of a Device or Driver
+type entropySourceHolder struct {
+	mu     sync.Mutex
+	source EntropySource
+}
+
+// SetEntropySource sets the source of the entropy sent when the device asks for it
+func (h *entropySourceHolder) SetEntropySource(source EntropySource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.source = source
+}
+
+// EntropySource returns the source of the entropy sent when the device asks for it
+func (h *entropySourceHolder) EntropySource() EntropySource {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.source == nil {
+		return DefaultEntropySource
+	}
+	return h.source
+}
+
+// answerEntropyRequests answers the EntropyRequest messages starting with msg with
+// entropy from source until the device sends another message, which is returned
+func answerEntropyRequests(dev usb.Device, msg *wire.Message, source EntropySource) (*wire.Message, error) {
+	for msg.Kind == uint16(messages.MessageType_MessageType_EntropyRequest) {
+		// the entropy is read first, a source failing or waiting for the user
+		// must not leave the device without answer while it is read
+		entropyChunks, err := MessageEntropyAckFrom(source, entropyBufferSize)
+		if err != nil {
+			return nil, err
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := writePackets(dev, entropyChunks); err != nil {
+				log.Errorf("entropy ack error: %v", err)
+			}
+		}()
+
+		msg, err = readMessage(dev)
+		if err != nil {
+			return nil, err
+		}
+		wg.Wait()
+	}
+	return msg, nil
+}
@@ -0,0 +1,134 @@
+package skywallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// testHelperEntropyDevice reads the answers of the device from in and records the messages sent in out
+type testHelperEntropyDevice struct {
+	in  bytes.Buffer
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func (d *testHelperEntropyDevice) Read(p []byte) (int, error) {
+	return d.in.Read(p)
+}
+
+func (d *testHelperEntropyDevice) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.out.Write(p)
+}
+
+func (d *testHelperEntropyDevice) Close(disconnect bool) error {
+	return nil
+}
+
+func TestFixedEntropy(t *testing.T) {
+	e := FixedEntropy{1, 2, 3}
+	b, err := e.Entropy(2)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2}, b)
+
+	_, err = e.Entropy(4)
+	require.EqualError(t, err, "fixed entropy has 3 bytes, 4 required")
+}
+
+func TestFileEntropy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "entropy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hwrng")
+	require.NoError(t, ioutil.WriteFile(path, []byte{9, 8, 7, 6}, 0600))
+
+	e := FileEntropy{Path: path}
+	b, err := e.Entropy(4)
+	require.NoError(t, err)
+	require.Equal(t, []byte{9, 8, 7, 6}, b)
+
+	_, err = e.Entropy(5)
+	require.Error(t, err)
+}
+
+func TestDiceEntropy(t *testing.T) {
+	rolls := strings.Repeat("1234561234", 10)
+
+	var asked []int
+	e := DiceEntropy{
+		ReadRolls: func(needed int) (string, error) {
+			asked = append(asked, needed)
+			// enter the rolls in two parts
+			if len(asked) == 1 {
+				return rolls[:60], nil
+			}
+			return "  " + rolls[60:], nil
+		},
+	}
+	require.Equal(t, "d6 dice", e.Name())
+	require.Equal(t, 100, e.RollsNeeded(32))
+
+	b, err := e.Entropy(32)
+	require.NoError(t, err)
+	require.Equal(t, []int{100, 40}, asked)
+	expected := sha256.Sum256([]byte(rolls))
+	require.Equal(t, expected[:], b)
+
+	e.ReadRolls = func(int) (string, error) {
+		return "1237", nil
+	}
+	_, err = e.Entropy(32)
+	require.EqualError(t, err, `invalid roll '7', rolls go from 1 to 6`)
+}
+
+func TestAnswerEntropyRequests(t *testing.T) {
+	entropyRequest, err := Encode(&messages.EntropyRequest{})
+	require.NoError(t, err)
+	success, err := Encode(&messages.Success{Message: proto.String("done")})
+	require.NoError(t, err)
+
+	dev := &testHelperEntropyDevice{}
+	for _, msg := range []wire.Message{entropyRequest, success} {
+		_, err := msg.WriteTo(&dev.in)
+		require.NoError(t, err)
+	}
+
+	first, err := wire.ReadFrom(&dev.in)
+	require.NoError(t, err)
+	entropy := FixedEntropy(bytes.Repeat([]byte{0xab}, entropyBufferSize))
+	msg, err := answerEntropyRequests(dev, first, entropy)
+	require.NoError(t, err)
+	require.Equal(t, success, *msg)
+
+	ack, err := wire.ReadFrom(&dev.out)
+	require.NoError(t, err)
+	entropyAck := &messages.EntropyAck{}
+	require.NoError(t, decodeAs(*ack, entropyAck))
+	require.Equal(t, []byte(entropy), entropyAck.Entropy)
+}
+
+func TestDeviceEntropySource(t *testing.T) {
+	device := getMockDevice(&MockDeviceDriver{})
+	require.Equal(t, DefaultEntropySource, device.EntropySource())
+
+	device.SetEntropySource(FixedEntropy{1})
+	require.Equal(t, FixedEntropy{1}, device.EntropySource())
+
+	driver, err := NewEmulatorDriver(EmulatorPort)
+	require.NoError(t, err)
+	NewDeviceWithDriver(driver).SetEntropySource(FileEntropy{Path: "/dev/hwrng"})
+	require.Equal(t, FileEntropy{Path: "/dev/hwrng"}, driver.EntropySource())
+}
@@ -0,0 +1,148 @@
+package skywallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// The errors of the device communication fall in three classes, told apart with errors.As:
+//
+//   - TransportError: the device could not be reached, i.e. it is not connected, the
+//     user is not allowed to open it or it was disconnected during an action
+//   - ProtocolError: the device answered something the host could not decode or did
+//     not expect, an UnexpectedMessageError names the expected and received types
+//   - DeviceError: the device answered with a Failure message, holding its FailureType
+//
+// Any other error is raised by the host before talking to the device, i.e. invalid arguments.
+
+// udevHint explains how to let the user open the device on linux
+const udevHint = "on linux copy udev/51-skywallet.rules to /etc/udev/rules.d, " +
+	"reload the rules and reconnect the device (see LINUX-SETUP.md)"
+
+// TransportError is returned if the device could not be reached
+type TransportError struct {
+	Err error
+}
+
+func (e TransportError) Error() string {
+	if e.PermissionDenied() {
+		return fmt.Sprintf("transport error: %v: %s", e.Err, udevHint)
+	}
+	return fmt.Sprintf("transport error: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e TransportError) Unwrap() error {
+	return e.Err
+}
+
+// NotFound tells whether no device is connected
+func (e TransportError) NotFound() bool {
+	return errors.Is(e.Err, ErrNoDeviceConnected) || errors.Is(e.Err, usb.ErrNotFound)
+}
+
+// Disconnected tells whether the device was disconnected during an action
+func (e TransportError) Disconnected() bool {
+	return errors.Is(e.Err, usb.ErrDisconnect) || errors.Is(e.Err, usb.ErrClosedDevice)
+}
+
+// PermissionDenied tells whether the user is not allowed to open the device
+func (e TransportError) PermissionDenied() bool {
+	return errors.Is(e.Err, usb.ErrPermissionDenied) || errors.Is(e.Err, os.ErrPermission)
+}
+
+// ProtocolError is returned if the device answered something the host could not decode or did not expect
+type ProtocolError struct {
+	Err error
+}
+
+func (e ProtocolError) Error() string {
+	return fmt.Sprintf("protocol error: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// UnexpectedMessageError is the ProtocolError of a message of another type than expected
+type UnexpectedMessageError struct {
+	Expected []messages.MessageType
+	Received messages.MessageType
+}
+
+func (e UnexpectedMessageError) Error() string {
+	expected := make([]string, len(e.Expected))
+	for i, kind := range e.Expected {
+		expected[i] = MessageTypeName(kind)
+	}
+	return fmt.Sprintf("expected %s, received %s", strings.Join(expected, " or "), MessageTypeName(e.Received))
+}
+
+// DeviceError is returned if the device answered with a Failure message
+type DeviceError struct {
+	Code    messages.FailureType
+	Message string
+}
+
+func (e DeviceError) Error() string {
+	return e.Message
+}
+
+// Cancelled tells whether the action was cancelled on the device or the PIN entry was cancelled
+func (e DeviceError) Cancelled() bool {
+	return e.Code == messages.FailureType_Failure_ActionCancelled || e.Code == messages.FailureType_Failure_PinCancelled
+}
+
+// DecodeFailure converts a Failure message into a DeviceError
+func DecodeFailure(msg wire.Message) error {
+	failure := &messages.Failure{}
+	if err := decodeAs(msg, failure); err != nil {
+		return err
+	}
+	return DeviceError{
+		Code:    failure.GetCode(),
+		Message: failure.GetMessage(),
+	}
+}
+
+// unexpectedMessage returns the ProtocolError of msg if it is not of the expected types.
+// A Failure message is returned as a DeviceError.
+func unexpectedMessage(msg wire.Message, expected ...messages.MessageType) error {
+	if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
+		return DecodeFailure(msg)
+	}
+	return ProtocolError{Err: UnexpectedMessageError{
+		Expected: expected,
+		Received: messages.MessageType(msg.Kind),
+	}}
+}
+
+// unsupportedFailure returns an UnsupportedError for feature if msg is the Failure
+// the firmware answers to a message type it does not know
+func unsupportedFailure(msg wire.Message, feature string) error {
+	if msg.Kind != uint16(messages.MessageType_MessageType_Failure) {
+		return nil
+	}
+	var deviceErr DeviceError
+	if errors.As(DecodeFailure(msg), &deviceErr) && deviceErr.Code == messages.FailureType_Failure_UnexpectedMessage {
+		return UnsupportedError{Feature: feature}
+	}
+	return nil
+}
+
+// readError classifies an error reading a message from the device
+func readError(err error) error {
+	var tooLarge wire.MessageTooLargeError
+	if errors.Is(err, wire.ErrMalformedMessage) || errors.Is(err, wire.ErrNoMessageHeader) || errors.As(err, &tooLarge) {
+		return ProtocolError{Err: err}
+	}
+	return TransportError{Err: err}
+}
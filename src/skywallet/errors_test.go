@@ -0,0 +1,147 @@
+package skywallet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestDecodeSuccess(t *testing.T) {
+	success, err := Encode(&messages.Success{Message: proto.String("Device wiped")})
+	require.NoError(t, err)
+	failure, err := Encode(&messages.Failure{
+		Code:    messages.FailureType_Failure_ActionCancelled.Enum(),
+		Message: proto.String("Action cancelled by user"),
+	})
+	require.NoError(t, err)
+	buttonRequest, err := Encode(&messages.ButtonRequest{})
+	require.NoError(t, err)
+
+	text, err := DecodeSuccess(success)
+	require.NoError(t, err)
+	require.Equal(t, "Device wiped", text)
+
+	_, err = DecodeSuccess(failure)
+	var deviceErr DeviceError
+	require.True(t, errors.As(err, &deviceErr))
+	require.Equal(t, messages.FailureType_Failure_ActionCancelled, deviceErr.Code)
+	require.True(t, deviceErr.Cancelled())
+	require.EqualError(t, err, "Action cancelled by user")
+
+	_, err = DecodeSuccess(buttonRequest)
+	var unexpected UnexpectedMessageError
+	require.True(t, errors.As(err, &unexpected))
+	require.Equal(t, messages.MessageType_MessageType_ButtonRequest, unexpected.Received)
+	require.EqualError(t, err, "protocol error: expected Success, received ButtonRequest")
+
+	_, err = DecodeSuccess(wire.Message{Kind: uint16(messages.MessageType_MessageType_Success), Data: []byte{0x0a, 0x05}})
+	require.True(t, errors.As(err, &ProtocolError{}))
+}
+
+func TestReadError(t *testing.T) {
+	tt := []struct {
+		err      error
+		protocol bool
+	}{
+		{err: wire.ErrMalformedMessage, protocol: true},
+		{err: wire.ErrNoMessageHeader, protocol: true},
+		{err: wire.MessageTooLargeError{Size: 11, MaxSize: 10}, protocol: true},
+		{err: usb.ErrDisconnect},
+		{err: io.ErrUnexpectedEOF},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			err := readError(tc.err)
+			require.True(t, errors.Is(err, tc.err))
+			require.Equal(t, tc.protocol, errors.As(err, &ProtocolError{}))
+			require.Equal(t, !tc.protocol, errors.As(err, &TransportError{}))
+		})
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	notFound := TransportError{Err: ErrNoDeviceConnected}
+	require.True(t, notFound.NotFound())
+	require.False(t, notFound.PermissionDenied())
+	require.EqualError(t, notFound, "transport error: no device connected")
+
+	disconnected := TransportError{Err: usb.ErrDisconnect}
+	require.True(t, disconnected.Disconnected())
+
+	for _, err := range []error{
+		fmt.Errorf("%w: libusb: bad access [code -3]", usb.ErrPermissionDenied),
+		&os.PathError{Op: "open", Path: "/dev/hidraw0", Err: os.ErrPermission},
+	} {
+		denied := TransportError{Err: err}
+		require.True(t, denied.PermissionDenied())
+		require.Contains(t, denied.Error(), "51-skywallet.rules")
+	}
+}
+
+// testHelperBus enumerates one device it fails to connect to,
+// or fails to enumerate with enumerateErr if set
+type testHelperBus struct {
+	err          error
+	enumerateErr error
+}
+
+func (b testHelperBus) Enumerate(vendorID, productID uint16) ([]usb.Info, error) {
+	if b.enumerateErr != nil {
+		return nil, b.enumerateErr
+	}
+	return []usb.Info{{Path: "lib0"}}, nil
+}
+
+func (b testHelperBus) Connect(path string) (usb.Device, error) {
+	return nil, b.err
+}
+
+func (b testHelperBus) Has(path string) bool {
+	return true
+}
+
+func (b testHelperBus) Close() {}
+
+func TestGetDeviceError(t *testing.T) {
+	denied := fmt.Errorf("%w: libusb: bad access [code -3]", usb.ErrPermissionDenied)
+	tt := []struct {
+		name string
+		bus  testHelperBus
+	}{
+		{
+			name: "connect",
+			bus:  testHelperBus{err: denied},
+		},
+		{
+			// libusb opens the devices while enumerating them
+			name: "enumerate",
+			bus:  testHelperBus{enumerateErr: denied},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &Driver{
+				deviceType: DeviceTypeUSB,
+				bus:        usb.Init(tc.bus),
+			}
+
+			dev, err := driver.GetDevice()
+			require.Nil(t, dev)
+			var transportErr TransportError
+			require.True(t, errors.As(err, &transportErr))
+			require.True(t, transportErr.PermissionDenied())
+			require.False(t, transportErr.NotFound())
+		})
+	}
+}
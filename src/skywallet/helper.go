@@ -1,18 +1,13 @@
 package skywallet
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"runtime"
-	"sync"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
-
 	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
 
 	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
 	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
@@ -53,6 +48,18 @@ const (
 	BitcoinCoinType
 )
 
+// String returns the coin type as accepted by CoinTypeFromString
+func (ct CoinType) String() string {
+	switch ct {
+	case SkycoinCoinType:
+		return "SKY"
+	case BitcoinCoinType:
+		return "BTC"
+	default:
+		return "invalid"
+	}
+}
+
 // CoinTypeFromString returns CoinType from String (i.e. SkycoinCoinType from 'SKY')
 func CoinTypeFromString(ct string) (CoinType, error) {
 	switch ct {
@@ -92,6 +99,8 @@ type DeviceDriver interface {
 type Driver struct {
 	deviceType DeviceType
 	bus        usb.Bus
+	// entropySourceHolder answers the EntropyRequest messages
+	entropySourceHolder
 }
 
 func initUsb() []usb.Bus {
@@ -120,20 +129,25 @@ func NewDriver(deviceType DeviceType) (*Driver, error) {
 			bus:        usb.Init(initUsb()...),
 		}, nil
 	case DeviceTypeEmulator:
-		udpBus, err := usb.InitUDP([]int{EmulatorPort})
-		if err != nil {
-			return nil, err
-		}
-
-		return &Driver{
-			deviceType: deviceType,
-			bus:        usb.Init(udpBus),
-		}, nil
+		return NewEmulatorDriver(EmulatorPort)
 	}
 
 	return nil, fmt.Errorf("invalid device %s", deviceType)
 }
 
+// NewEmulatorDriver creates a driver for the emulator listening on the given udp port
+func NewEmulatorDriver(port int) (*Driver, error) {
+	udpBus, err := usb.InitUDP([]int{port})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Driver{
+		deviceType: DeviceTypeEmulator,
+		bus:        usb.Init(udpBus),
+	}, nil
+}
+
 // Close closes the bus
 func (drv *Driver) Close() {
 	drv.bus.Close()
@@ -151,7 +165,7 @@ func (drv *Driver) SendToDeviceNoAnswer(dev usb.Device, chunks [][64]byte) error
 
 // SendToDevice sends msg to device and returns response
 func (drv *Driver) SendToDevice(dev usb.Device, chunks [][64]byte) (wire.Message, error) {
-	return sendToDevice(dev, chunks)
+	return sendToDevice(dev, chunks, drv.EntropySource())
 }
 
 // GetDevice returns a device instance
@@ -165,27 +179,27 @@ func (drv *Driver) GetDevice() (usb.Device, error) {
 		return nil, fmt.Errorf("invalid device type: %s", drv.deviceType)
 	}
 
+	// libusb opens the devices while enumerating them, so a device the user is
+	// not allowed to open fails here and must not be reported as missing
 	infos, err := drv.bus.Enumerate(vendorID, productID)
-	if len(infos) <= 0 {
-		return nil, ErrNoDeviceConnected
+	if err != nil {
+		return nil, TransportError{Err: err}
 	}
 
-	if err != nil {
-		return nil, err
+	if len(infos) <= 0 {
+		return nil, TransportError{Err: ErrNoDeviceConnected}
 	}
 
-	tries := 0
-	for tries < 3 {
-		dev, err := drv.bus.Connect(infos[0].Path)
-		if err != nil {
-			log.Print(err.Error())
-			tries++
-			time.Sleep(100 * time.Millisecond)
-		} else {
-			return dev, err
+	for tries := 0; tries < 3; tries++ {
+		var dev usb.Device
+		dev, err = drv.bus.Connect(infos[0].Path)
+		if err == nil {
+			return dev, nil
 		}
+		log.Print(err.Error())
+		time.Sleep(100 * time.Millisecond)
 	}
-	return nil, err
+	return nil, TransportError{Err: err}
 }
 
 // GetDeviceInfos returns information from the attached usb
@@ -202,56 +216,22 @@ func (drv *Driver) GetDeviceInfos() ([]usb.Info, error) {
 }
 
 func sendToDeviceNoAnswer(dev usb.Device, chunks [][64]byte) error {
-	for _, element := range chunks {
-		_, err := dev.Write(element[:])
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return writePackets(dev, chunks)
 }
 
-func sendToDevice(dev usb.Device, chunks [][64]byte) (wire.Message, error) {
-	var msg *wire.Message
-	var err error
-	for _, element := range chunks {
-		_, err := dev.Write(element[:])
-		if err != nil {
-			return wire.Message{}, err
-		}
+func sendToDevice(dev usb.Device, chunks [][64]byte, source EntropySource) (wire.Message, error) {
+	if err := writePackets(dev, chunks); err != nil {
+		return wire.Message{}, err
 	}
 
-	msg, err = wire.ReadFrom(dev)
+	msg, err := readMessage(dev)
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	for msg.Kind == uint16(messages.MessageType_MessageType_EntropyRequest) {
-		var wg sync.WaitGroup
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-			entropyChunks, err := MessageEntropyAck(entropyBufferSize)
-			if err != nil {
-				log.Errorf("failed to create entropy ack msg: %v", err)
-				return
-			}
-
-			for _, element := range entropyChunks {
-				_, err := dev.Write(element[:])
-				if err != nil {
-					log.Errorf("entropy ack error: %v", err)
-					return
-				}
-			}
-		}()
-
-		msg, err = wire.ReadFrom(dev)
-		if err != nil {
-			return wire.Message{}, err
-		}
-		wg.Wait()
+	msg, err = answerEntropyRequests(dev, msg, source)
+	if err != nil {
+		return wire.Message{}, err
 	}
 	for msg.Kind == uint16(messages.MessageType_MessageType_Success) {
 		success, err := decodeSuccessMsgStruct(*msg)
@@ -259,7 +239,7 @@ func sendToDevice(dev usb.Device, chunks [][64]byte) (wire.Message, error) {
 			return wire.Message{}, err
 		}
 		if success.MsgType != nil && *success.MsgType == messages.MessageType(messages.MessageType_MessageType_EntropyAck) {
-			msg, err = wire.ReadFrom(dev)
+			msg, err = readMessage(dev)
 			if err != nil {
 				return wire.Message{}, err
 			}
@@ -271,37 +251,6 @@ func sendToDevice(dev usb.Device, chunks [][64]byte) (wire.Message, error) {
 	return *msg, err
 }
 
-func binaryWrite(message io.Writer, data interface{}) {
-	err := binary.Write(message, binary.BigEndian, data)
-	if err != nil {
-		log.Panic(err)
-	}
-}
-
-func makeSkyWalletMessage(data []byte, msgID messages.MessageType) [][64]byte {
-	message := new(bytes.Buffer)
-	binaryWrite(message, []byte("##"))
-	binaryWrite(message, uint16(msgID))
-	binaryWrite(message, uint32(len(data)))
-	binaryWrite(message, []byte("\n"))
-	if len(data) > 0 {
-		binaryWrite(message, data[1:])
-	}
-
-	messageLen := message.Len()
-	var chunks [][64]byte
-	i := 0
-	for messageLen > 0 {
-		var chunk [64]byte
-		chunk[0] = '?'
-		copy(chunk[1:], message.Bytes()[63*i:63*(i+1)])
-		chunks = append(chunks, chunk)
-		messageLen -= 63
-		i = i + 1
-	}
-	return chunks
-}
-
 // Initialize send an init request to the device
 func Initialize(dev usb.Device) error {
 	var chunks [][64]byte
@@ -310,7 +259,7 @@ func Initialize(dev usb.Device) error {
 	if err != nil {
 		return err
 	}
-	_, err = sendToDevice(dev, chunks)
+	_, err = sendToDevice(dev, chunks, DefaultEntropySource)
 	return err
 }
 
@@ -323,19 +272,24 @@ func DecodeSuccessOrFailMsg(msg wire.Message) (string, error) {
 		return DecodeFailMsg(msg)
 	}
 
-	return "", fmt.Errorf("calling DecodeSuccessOrFailMsg on message kind %s", messages.MessageType(msg.Kind))
+	return "", unexpectedMessage(msg, messages.MessageType_MessageType_Success, messages.MessageType_MessageType_Failure)
+}
+
+// DecodeSuccess returns the message of a Success, a DeviceError for a Failure
+// and a ProtocolError for any other message
+func DecodeSuccess(msg wire.Message) (string, error) {
+	if msg.Kind != uint16(messages.MessageType_MessageType_Success) {
+		return "", unexpectedMessage(msg, messages.MessageType_MessageType_Success)
+	}
+	return DecodeSuccessMsg(msg)
 }
 
 func decodeSuccessMsgStruct(msg wire.Message) (messages.Success, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_Success) {
-		success := messages.Success{}
-		err := proto.Unmarshal(msg.Data, &success)
-		if err != nil {
-			return messages.Success{}, err
-		}
-		return success, nil
+	success := messages.Success{}
+	if err := decodeAs(msg, &success); err != nil {
+		return messages.Success{}, err
 	}
-	return messages.Success{}, fmt.Errorf("calling DecodeSuccessMsg with wrong message type: %s", messages.MessageType(msg.Kind))
+	return success, nil
 }
 
 // DecodeSuccessMsg convert byte data into string containing the success message returned by the device
@@ -349,69 +303,77 @@ func DecodeSuccessMsg(msg wire.Message) (string, error) {
 
 // DecodeFailMsg convert byte data into string containing the failure returned by the device
 func DecodeFailMsg(msg wire.Message) (string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
-		failure := &messages.Failure{}
-		err := proto.Unmarshal(msg.Data, failure)
-		if err != nil {
-			return "", err
-		}
-		return failure.GetMessage(), nil
+	failure := &messages.Failure{}
+	if err := decodeAs(msg, failure); err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("calling DecodeFailMsg with wrong message type: %s", messages.MessageType(msg.Kind))
+	return failure.GetMessage(), nil
 }
 
 // DecodeResponseSkycoinAddress convert byte data into list of addresses, meant to be used after DevicePinMatrixAck
 func DecodeResponseSkycoinAddress(msg wire.Message) ([]string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) {
-		responseSkycoinAddress := &messages.ResponseSkycoinAddress{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinAddress)
-		if err != nil {
-			return []string{}, err
-		}
-		return responseSkycoinAddress.GetAddresses(), nil
+	responseSkycoinAddress := &messages.ResponseSkycoinAddress{}
+	if err := decodeAs(msg, responseSkycoinAddress); err != nil {
+		return []string{}, err
 	}
-
-	return []string{}, fmt.Errorf("calling DecodeResponseSkycoinAddress with wrong message type: %s", messages.MessageType(msg.Kind))
+	return responseSkycoinAddress.GetAddresses(), nil
 }
 
 // DecodeResponseTransactionSign convert byte data into list of signatures
 func DecodeResponseTransactionSign(msg wire.Message) ([]string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseTransactionSign) {
-		responseSkycoinTransactionSign := &messages.ResponseTransactionSign{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinTransactionSign)
-		if err != nil {
-			return make([]string, 0), err
-		}
-		return responseSkycoinTransactionSign.GetSignatures(), nil
+	responseSkycoinTransactionSign := &messages.ResponseTransactionSign{}
+	if err := decodeAs(msg, responseSkycoinTransactionSign); err != nil {
+		return []string{}, err
 	}
-
-	return []string{}, fmt.Errorf("calling DecodeResponseeSkycoinSignMessage with wrong message type: %s", messages.MessageType(msg.Kind))
+	return responseSkycoinTransactionSign.GetSignatures(), nil
 }
 
 // DecodeResponseSkycoinSignMessage convert byte data into signed message, meant to be used after DevicePinMatrixAck
 func DecodeResponseSkycoinSignMessage(msg wire.Message) (string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage) {
-		responseSkycoinSignMessage := &messages.ResponseSkycoinSignMessage{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinSignMessage)
+	responseSkycoinSignMessage := &messages.ResponseSkycoinSignMessage{}
+	if err := decodeAs(msg, responseSkycoinSignMessage); err != nil {
+		return "", err
+	}
+	return responseSkycoinSignMessage.GetSignedMessage(), nil
+}
+
+// DecodeResponseSignMessage convert byte data into a signature in the format of the coin type,
+// hex for skycoin and base64 Bitcoin Signed Message for bitcoin
+func DecodeResponseSignMessage(msg wire.Message, coinType CoinType) (string, error) {
+	signature, err := DecodeResponseSkycoinSignMessage(msg)
+	if err != nil {
+		return "", err
+	}
+	switch coinType {
+	case SkycoinCoinType:
+		return signature, nil
+	case BitcoinCoinType:
+		sig, err := cipher.SigFromHex(signature)
 		if err != nil {
 			return "", err
 		}
-		return responseSkycoinSignMessage.GetSignedMessage(), nil
+		return EncodeBitcoinMessageSignature(sig), nil
+	default:
+		return "", fmt.Errorf("invalid coin type: %s", coinType)
 	}
-	return "", fmt.Errorf("calling DecodeResponseeSkycoinSignMessage with wrong message type: %s", messages.MessageType(msg.Kind))
 }
 
 // DecodeResponseEntropyMessage convert byte data into entropy message, meant to be used after GetEntropy
 func DecodeResponseEntropyMessage(msg wire.Message) (*messages.Entropy, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_Entropy) {
-		responseEntropyMessage := &messages.Entropy{}
-		err := proto.Unmarshal(msg.Data, responseEntropyMessage)
-		if err != nil {
-			return nil, err
-		}
-		return responseEntropyMessage, nil
+	responseEntropyMessage := &messages.Entropy{}
+	if err := decodeAs(msg, responseEntropyMessage); err != nil {
+		return nil, err
+	}
+	return responseEntropyMessage, nil
+}
+
+// DecodeFeaturesMsg convert byte data into device features, meant to be used after GetFeatures
+func DecodeFeaturesMsg(msg wire.Message) (*messages.Features, error) {
+	features := &messages.Features{}
+	if err := decodeAs(msg, features); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("calling DecodeResponseEntropyMessage with wrong message type: %s", messages.MessageType(msg.Kind))
+	return features, nil
 }
 
 // Does OS allow sync canceling via our custom libusb patches?
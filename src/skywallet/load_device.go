@@ -0,0 +1,78 @@
+package skywallet
+
+import (
+	"errors"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+var (
+	// ErrLoadDeviceSeed is returned if LoadDeviceOptions holds both or none of a mnemonic and an HD node
+	ErrLoadDeviceSeed = errors.New("load device needs either a mnemonic or an HD node")
+	// ErrInvalidPin is returned if a PIN code holds other characters than the digits 1 to 9
+	ErrInvalidPin = errors.New("PIN code must only hold the digits 1 to 9")
+)
+
+// LoadDeviceOptions is the state LoadDevice puts the device in
+type LoadDeviceOptions struct {
+	// Mnemonic or Node is the seed of the device
+	Mnemonic string
+	Node     *messages.HDNodeType
+	// Pin is the PIN code in plain digits, no PIN is set if empty
+	Pin                  string
+	PassphraseProtection bool
+	Language             string
+	Label                string
+	// SkipChecksum loads a mnemonic without checking it is a valid BIP-39 mnemonic
+	SkipChecksum bool
+}
+
+// Validate checks the options hold a single seed and a valid PIN code.
+// The mnemonic is checked to be a valid BIP-39 mnemonic unless SkipChecksum is set.
+func (opts LoadDeviceOptions) Validate() error {
+	if (opts.Mnemonic == "") == (opts.Node == nil) {
+		return ErrLoadDeviceSeed
+	}
+	if opts.Mnemonic != "" && !opts.SkipChecksum {
+		if err := bip39.ValidateMnemonic(opts.Mnemonic); err != nil {
+			return err
+		}
+	}
+	if strings.Trim(opts.Pin, "123456789") != "" {
+		return ErrInvalidPin
+	}
+	return nil
+}
+
+// LoadDevice puts the device in the state described by opts in a single message,
+// to prepare test fixtures. Only the emulator accepts it, an UnsupportedError is
+// returned for other devices.
+func (d *Device) LoadDevice(opts LoadDeviceOptions) (wire.Message, error) {
+	if err := opts.Validate(); err != nil {
+		return wire.Message{}, err
+	}
+
+	info, err := d.DeviceInfo()
+	if err != nil {
+		return wire.Message{}, err
+	}
+	if err := info.RequireLoadDevice(); err != nil {
+		return wire.Message{}, err
+	}
+
+	if err := d.Connect(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	loadDeviceChunks, err := MessageLoadDevice(opts)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.Driver.SendToDevice(d.dev, loadDeviceChunks)
+}
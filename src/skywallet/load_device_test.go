@@ -0,0 +1,99 @@
+package skywallet
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "cloud flower upset remain green metal below cup stem infant art thank"
+
+func TestLoadDeviceOptionsValidate(t *testing.T) {
+	tt := []struct {
+		name string
+		opts LoadDeviceOptions
+		err  error
+	}{
+		{
+			name: "mnemonic",
+			opts: LoadDeviceOptions{Mnemonic: testMnemonic, Pin: "1234"},
+		},
+		{
+			name: "node",
+			opts: LoadDeviceOptions{Node: &messages.HDNodeType{}},
+		},
+		{
+			name: "no seed",
+			opts: LoadDeviceOptions{},
+			err:  ErrLoadDeviceSeed,
+		},
+		{
+			name: "mnemonic and node",
+			opts: LoadDeviceOptions{Mnemonic: testMnemonic, Node: &messages.HDNodeType{}},
+			err:  ErrLoadDeviceSeed,
+		},
+		{
+			name: "pin with zero",
+			opts: LoadDeviceOptions{Mnemonic: testMnemonic, Pin: "1230"},
+			err:  ErrInvalidPin,
+		},
+		{
+			name: "skip checksum",
+			opts: LoadDeviceOptions{Mnemonic: "cloud cloud cloud", SkipChecksum: true},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.err, tc.opts.Validate())
+		})
+	}
+
+	require.Error(t, LoadDeviceOptions{Mnemonic: "cloud cloud cloud"}.Validate())
+}
+
+func TestLoadDevice(t *testing.T) {
+	success, err := Encode(&messages.Success{Message: proto.String("Device loaded")})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		features *messages.Features
+		err      error
+	}{
+		{
+			name:     "emulator",
+			features: &messages.Features{FirmwareFeatures: proto.Uint32(1 << 2)},
+		},
+		{
+			name:     "device",
+			features: &messages.Features{},
+			err:      UnsupportedError{Feature: "load device"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			features, err := Encode(tc.features)
+			require.NoError(t, err)
+
+			driverMock := &MockDeviceDriver{}
+			driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(features, nil).Once()
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(success, nil).Once()
+			device := getMockDevice(driverMock)
+
+			msg, err := device.LoadDevice(LoadDeviceOptions{Mnemonic: testMnemonic, Label: "fixture"})
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				driverMock.AssertNumberOfCalls(t, "SendToDevice", 1)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, success, msg)
+		})
+	}
+}
@@ -2,9 +2,9 @@ package skywallet
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 
-	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/gogo/protobuf/proto"
 
 	messages "github.com/skycoin/hardware-wallet-protob/go"
@@ -12,128 +12,81 @@ import (
 
 // MessageCancel prepare Cancel request
 func MessageCancel() ([][64]byte, error) {
-	msg := &messages.Cancel{}
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Cancel)
-	return chunks, nil
+	return encodePackets(&messages.Cancel{})
 }
 
 // MessageButtonAck send this message (before user action) when the device expects the user to push a button
 func MessageButtonAck() ([][64]byte, error) {
-	buttonAck := &messages.ButtonAck{}
-	data, err := proto.Marshal(buttonAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ButtonAck)
-	return chunks, nil
+	return encodePackets(&messages.ButtonAck{})
 }
 
 // MessagePassphraseAck send this message when the device expects receiving a Passphrase
 func MessagePassphraseAck(passphrase string) ([][64]byte, error) {
-	msg := &messages.PassphraseAck{
+	return MessagePassphraseAckState(passphrase, nil)
+}
+
+// MessagePassphraseAckState prepare a PassphraseAck holding the passphrase state previously
+// reported by the device, if any, so that it derives the new state with the same salt
+func MessagePassphraseAckState(passphrase string, state []byte) ([][64]byte, error) {
+	return encodePackets(&messages.PassphraseAck{
 		Passphrase: proto.String(passphrase),
-	}
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_PassphraseAck)
-	return chunks, nil
+		State:      state,
+	})
+}
+
+// MessagePassphraseStateAck acknowledges the passphrase state sent by the device
+func MessagePassphraseStateAck() ([][64]byte, error) {
+	return encodePackets(&messages.PassphraseStateAck{})
 }
 
 // MessageWordAck send this message between each word of the seed (before user action) during device backup
 func MessageWordAck(word string) ([][64]byte, error) {
-	wordAck := &messages.WordAck{
+	return encodePackets(&messages.WordAck{
 		Word: proto.String(word),
-	}
-	data, err := proto.Marshal(wordAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_WordAck)
-	return chunks, nil
+	})
 }
 
 // MessageCheckMessageSignature prepare CheckMessageSignature request
 func MessageCheckMessageSignature(message, signature, address string) ([][64]byte, error) {
-	msg := &messages.SkycoinCheckMessageSignature{
+	return encodePackets(&messages.SkycoinCheckMessageSignature{
 		Address:   proto.String(address),
 		Message:   proto.String(message),
 		Signature: proto.String(signature),
-	}
-
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinCheckMessageSignature)
-	return chunks, nil
+	})
 }
 
 // MessageAddressGen prepare MessageAddressGen request
 func MessageAddressGen(addressN, startIndex uint32, confirmAddress bool, coinType CoinType) ([][64]byte, error) {
-	var chunks [][64]byte
 	switch coinType {
 	case SkycoinCoinType:
-		address := &messages.SkycoinAddress{
+		return encodePackets(&messages.SkycoinAddress{
 			AddressN:       proto.Uint32(addressN),
 			ConfirmAddress: proto.Bool(confirmAddress),
 			StartIndex:     proto.Uint32(startIndex),
-		}
-		data, err := proto.Marshal(address)
-		if err != nil {
-			return [][64]byte{}, err
-		}
-		chunks = makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinAddress)
+		})
 	case BitcoinCoinType:
-		address := &messages.BitcoinAddress{
+		return encodePackets(&messages.BitcoinAddress{
 			AddressN:       proto.Uint32(addressN),
 			ConfirmAddress: proto.Bool(confirmAddress),
 			StartIndex:     proto.Uint32(startIndex),
-		}
-		data, err := proto.Marshal(address)
-		if err != nil {
-			return [][64]byte{}, err
-		}
-		chunks = makeSkyWalletMessage(data, messages.MessageType_MessageType_BitcoinAddress)
-
+		})
 	}
 
-	return chunks, nil
+	return [][64]byte{}, nil
 }
 
 // MessageDeviceGetRawEntropy prepare GetEntropy request
 func MessageDeviceGetRawEntropy(entropyBytes uint32) ([][64]byte, error) {
-	getEntropy := &messages.GetRawEntropy{
+	return encodePackets(&messages.GetRawEntropy{
 		Size_: &entropyBytes,
-	}
-
-	data, err := proto.Marshal(getEntropy)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GetRawEntropy)
-	return chunks, nil
+	})
 }
 
 // MessageDeviceGetMixedEntropy prepare GetMixedEntropy request
 func MessageDeviceGetMixedEntropy(entropyBytes uint32) ([][64]byte, error) {
-	getEntropy := &messages.GetMixedEntropy{
+	return encodePackets(&messages.GetMixedEntropy{
 		Size_: &entropyBytes,
-	}
-
-	data, err := proto.Marshal(getEntropy)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GetMixedEntropy)
-	return chunks, nil
+	})
 }
 
 // MessageApplySettings prepare MessageApplySettings request
@@ -145,25 +98,12 @@ func MessageApplySettings(usePassphrase *bool, label string, language string) ([
 	if usePassphrase != nil {
 		applySettings.UsePassphrase = proto.Bool(*usePassphrase)
 	}
-	log.Println(applySettings)
-	data, err := proto.Marshal(applySettings)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ApplySettings)
-	return chunks, nil
+	return encodePackets(applySettings)
 }
 
 // MessageBackup prepare MessageBackup request
 func MessageBackup() ([][64]byte, error) {
-	backupDevice := &messages.BackupDevice{}
-	data, err := proto.Marshal(backupDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_BackupDevice)
-	return chunks, nil
+	return encodePackets(&messages.BackupDevice{})
 }
 
 // MessageChangePin prepare MessageChangePin request
@@ -172,82 +112,50 @@ func MessageChangePin(remove *bool) ([][64]byte, error) {
 	if remove != nil {
 		changePin.Remove = proto.Bool(*remove)
 	}
-	data, err := proto.Marshal(changePin)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ChangePin)
-	return chunks, nil
+	return encodePackets(changePin)
 }
 
 // MessageConnected prepare MessageConnected request
 func MessageConnected() ([][64]byte, error) {
-	msgRaw := &messages.Ping{}
-	data, err := proto.Marshal(msgRaw)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Ping)
-	return chunks, nil
+	return encodePackets(&messages.Ping{})
+}
+
+// MessagePing prepare MessagePing request
+func MessagePing(message string, buttonProtection, pinProtection, passphraseProtection bool) ([][64]byte, error) {
+	return encodePackets(&messages.Ping{
+		Message:              proto.String(message),
+		ButtonProtection:     proto.Bool(buttonProtection),
+		PinProtection:        proto.Bool(pinProtection),
+		PassphraseProtection: proto.Bool(passphraseProtection),
+	})
 }
 
 // MessageFirmwareErase prepare MessageFirmwareErase request
 func MessageFirmwareErase(payload []byte) ([][64]byte, error) {
-	deviceFirmwareErase := &messages.FirmwareErase{
+	return encodePackets(&messages.FirmwareErase{
 		Length: proto.Uint32(uint32(len(payload))),
-	}
-
-	erasedata, err := proto.Marshal(deviceFirmwareErase)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(erasedata, messages.MessageType_MessageType_FirmwareErase)
-	return chunks, nil
+	})
 }
 
 // MessageFirmwareUpload prepare MessageFirmwareUpload request
 func MessageFirmwareUpload(payload []byte, hash [32]byte) ([][64]byte, error) {
-	deviceFirmwareUpload := &messages.FirmwareUpload{
+	return encodePackets(&messages.FirmwareUpload{
 		Payload: payload,
 		Hash:    hash[:],
-	}
-
-	uploaddata, err := proto.Marshal(deviceFirmwareUpload)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(uploaddata, messages.MessageType_MessageType_FirmwareUpload)
-	return chunks, nil
+	})
 }
 
 // MessageGetFeatures prepare MessageGetFeatures request
 func MessageGetFeatures() ([][64]byte, error) {
-	featureMsg := &messages.GetFeatures{}
-	data, err := proto.Marshal(featureMsg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GetFeatures)
-	return chunks, nil
+	return encodePackets(&messages.GetFeatures{})
 }
 
 // MessageGenerateMnemonic prepare MessageGenerateMnemonic request
 func MessageGenerateMnemonic(wordCount uint32, usePassphrase bool) ([][64]byte, error) {
-	skycoinGenerateMnemonic := &messages.GenerateMnemonic{
+	return encodePackets(&messages.GenerateMnemonic{
 		PassphraseProtection: proto.Bool(usePassphrase),
 		WordCount:            proto.Uint32(wordCount),
-	}
-
-	data, err := proto.Marshal(skycoinGenerateMnemonic)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GenerateMnemonic)
-	return chunks, nil
+	})
 }
 
 // MessageRecovery prepare MessageRecovery request
@@ -259,45 +167,54 @@ func MessageRecovery(wordCount uint32, usePassphrase *bool, dryRun bool) ([][64]
 	if usePassphrase != nil {
 		recoveryDevice.PassphraseProtection = proto.Bool(*usePassphrase)
 	}
-	data, err := proto.Marshal(recoveryDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_RecoveryDevice)
-
-	return chunks, nil
+	return encodePackets(recoveryDevice)
 }
 
 // MessageSetMnemonic prepare MessageSetMnemonic request
 func MessageSetMnemonic(mnemonic string) ([][64]byte, error) {
-	skycoinSetMnemonic := &messages.SetMnemonic{
+	return encodePackets(&messages.SetMnemonic{
 		Mnemonic: proto.String(mnemonic),
-	}
-
-	data, err := proto.Marshal(skycoinSetMnemonic)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SetMnemonic)
-	return chunks, nil
+	})
 }
 
-// MessageSignMessage prepare MessageSignMessage request
-func MessageSignMessage(addressIndex int, message string) ([][64]byte, error) {
-	skycoinSignMessage := &messages.SkycoinSignMessage{
-		AddressN: proto.Uint32(uint32(addressIndex)),
-		Message:  proto.String(message),
+// MessageLoadDevice prepare MessageLoadDevice request
+func MessageLoadDevice(opts LoadDeviceOptions) ([][64]byte, error) {
+	loadDevice := &messages.LoadDevice{
+		Node:                 opts.Node,
+		PassphraseProtection: proto.Bool(opts.PassphraseProtection),
+		SkipChecksum:         proto.Bool(opts.SkipChecksum),
+	}
+	if opts.Mnemonic != "" {
+		loadDevice.Mnemonic = proto.String(opts.Mnemonic)
+	}
+	if opts.Pin != "" {
+		loadDevice.Pin = proto.String(opts.Pin)
+	}
+	if opts.Language != "" {
+		loadDevice.Language = proto.String(opts.Language)
 	}
+	if opts.Label != "" {
+		loadDevice.Label = proto.String(opts.Label)
+	}
+	return encodePackets(loadDevice)
+}
 
-	data, err := proto.Marshal(skycoinSignMessage)
-	if err != nil {
-		return [][64]byte{}, err
+// MessageSignMessage prepare MessageSignMessage request.
+// Bitcoin messages are hashed in the Bitcoin Signed Message format and the
+// device is asked to sign the digest.
+func MessageSignMessage(addressIndex int, message string, coinType CoinType) ([][64]byte, error) {
+	switch coinType {
+	case SkycoinCoinType:
+	case BitcoinCoinType:
+		message = BitcoinMessageHash(message).Hex()
+	default:
+		return [][64]byte{}, fmt.Errorf("invalid coin type: %s", coinType)
 	}
 
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinSignMessage)
-	return chunks, nil
+	return encodePackets(&messages.SkycoinSignMessage{
+		AddressN: proto.Uint32(uint32(addressIndex)),
+		Message:  proto.String(message),
+	})
 }
 
 // MessageTransactionSign prepare MessageTransactionSign request
@@ -308,15 +225,7 @@ func MessageTransactionSign(inputs []*messages.SkycoinTransactionInput, outputs
 		TransactionIn:  inputs,
 		TransactionOut: outputs,
 	}
-	log.Println(skycoinTransactionSignMessage)
-
-	data, err := proto.Marshal(skycoinTransactionSignMessage)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_TransactionSign)
-	return chunks, nil
+	return encodePackets(skycoinTransactionSignMessage)
 }
 
 // MessageSignTx prepare MessageSignTx request
@@ -329,15 +238,7 @@ func MessageSignTx(outputsCount int, inputsCount int, coinName string, version i
 		LockTime:     proto.Uint32(uint32(lockTime)),
 		TxHash:       proto.String(txHash),
 	}
-	log.Println(signTxMessage)
-
-	data, err := proto.Marshal(signTxMessage)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SignTx)
-	return chunks, nil
+	return encodePackets(signTxMessage)
 }
 
 // MessageTxAck prepare MessageTxAck request
@@ -348,16 +249,9 @@ func MessageTxAck(inputs []*messages.TxAck_TransactionType_TxInputType, outputs
 		LockTime: proto.Uint32(uint32(lockTime)),
 		Version:  proto.Uint32(uint32(version)),
 	}
-	txAckMessage := &messages.TxAck{
+	return encodePackets(&messages.TxAck{
 		Tx: tx,
-	}
-	data, err := proto.Marshal(txAckMessage)
-
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_TxAck)
-	return chunks, nil
+	})
 }
 
 // BitcoinMessageTxAck prepare MessageTxAck request
@@ -366,71 +260,78 @@ func BitcoinMessageTxAck(inputs []*messages.BitcoinTransactionInput, outputs []*
 		Inputs:  inputs,
 		Outputs: outputs,
 	}
-	txAckMessage := &messages.BitcoinTxAck{
+	return encodePackets(&messages.BitcoinTxAck{
 		Tx: tx,
-	}
-	data, err := proto.Marshal(txAckMessage)
-
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_BitcoinTxAck)
-	return chunks, nil
+	})
 }
 
 // MessageWipe prepare MessageWipe request
 func MessageWipe() ([][64]byte, error) {
-	wipeDevice := &messages.WipeDevice{}
-	data, err := proto.Marshal(wipeDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_WipeDevice)
-	return chunks, nil
+	return encodePackets(&messages.WipeDevice{})
 }
 
 // MessagePinMatrixAck prepare MessagePinMatrixAck request
 func MessagePinMatrixAck(p string) ([][64]byte, error) {
-	pinAck := &messages.PinMatrixAck{
+	return encodePackets(&messages.PinMatrixAck{
 		Pin: proto.String(p),
-	}
-	data, err := proto.Marshal(pinAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_PinMatrixAck)
-	return chunks, nil
+	})
 }
 
-// MessageEntropyAck prepare MessageEntropyAck request
+// MessageEntropyAck prepare MessageEntropyAck request with entropy from DefaultEntropySource
 func MessageEntropyAck(bufferSize int) ([][64]byte, error) {
-	buffer := cipher.RandByte(bufferSize)
+	return MessageEntropyAckFrom(DefaultEntropySource, bufferSize)
+}
+
+// MessageEntropyAckFrom prepare MessageEntropyAck request with entropy from source.
+// Only the hash of the entropy is logged, so the contribution can be audited without revealing it.
+func MessageEntropyAckFrom(source EntropySource, bufferSize int) ([][64]byte, error) {
+	buffer, err := source.Entropy(bufferSize)
+	if err != nil {
+		return nil, err
+	}
 	if len(buffer) != bufferSize {
 		return nil, fmt.Errorf("required %d bytes but got %d", bufferSize, len(buffer))
 	}
-	entropyAck := &messages.EntropyAck{
+	log.Infof("Sending %d bytes of host entropy from %s with sha256 %x", len(buffer), source.Name(), sha256.Sum256(buffer))
+	return encodePackets(&messages.EntropyAck{
 		Entropy: buffer,
+	})
+}
+
+// MessageResetDevice prepare MessageResetDevice request
+func MessageResetDevice(opts ResetDeviceOptions) ([][64]byte, error) {
+	resetDevice := &messages.ResetDevice{
+		DisplayRandom:        proto.Bool(opts.DisplayRandom),
+		PassphraseProtection: proto.Bool(opts.PassphraseProtection),
+		PinProtection:        proto.Bool(opts.PinProtection),
+		SkipBackup:           proto.Bool(opts.SkipBackup),
 	}
-	data, err := proto.Marshal(entropyAck)
-	if err != nil {
-		return nil, err
+	if opts.Strength != 0 {
+		resetDevice.Strength = proto.Uint32(opts.Strength)
 	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_EntropyAck)
-	return chunks, nil
+	if opts.Language != "" {
+		resetDevice.Language = proto.String(opts.Language)
+	}
+	if opts.Label != "" {
+		resetDevice.Label = proto.String(opts.Label)
+	}
+	if opts.U2FCounter != 0 {
+		resetDevice.U2FCounter = proto.Uint32(opts.U2FCounter)
+	}
+	return encodePackets(resetDevice)
 }
 
 // MessageInitialize prepare MessageInitialize request
 func MessageInitialize() ([][64]byte, error) {
-	initialize := &messages.Initialize{}
-	data, err := proto.Marshal(initialize)
-	if err != nil {
-		return nil, err
-	}
+	return MessageInitializeState(nil)
+}
 
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Initialize)
-	return chunks, nil
+// MessageInitializeState prepare an Initialize request resuming the session
+// of the passphrase state previously reported by the device, if any
+func MessageInitializeState(state []byte) ([][64]byte, error) {
+	return encodePackets(&messages.Initialize{
+		State: state,
+	})
 }
 
 // MessageSimulateButtonPress prespares a emulator button press simulation button
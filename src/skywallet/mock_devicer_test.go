@@ -399,20 +399,20 @@ func (_m *MockDevicer) SetMnemonic(mnemonic string) (wire.Message, error) {
 	return r0, r1
 }
 
-// SignMessage provides a mock function with given fields: addressIndex, message
-func (_m *MockDevicer) SignMessage(addressIndex int, message string) (wire.Message, error) {
-	ret := _m.Called(addressIndex, message)
+// SignMessage provides a mock function with given fields: addressIndex, message, coinType
+func (_m *MockDevicer) SignMessage(addressIndex int, message string, coinType CoinType) (wire.Message, error) {
+	ret := _m.Called(addressIndex, message, coinType)
 
 	var r0 wire.Message
-	if rf, ok := ret.Get(0).(func(int, string) wire.Message); ok {
-		r0 = rf(addressIndex, message)
+	if rf, ok := ret.Get(0).(func(int, string, CoinType) wire.Message); ok {
+		r0 = rf(addressIndex, message, coinType)
 	} else {
 		r0 = ret.Get(0).(wire.Message)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, string) error); ok {
-		r1 = rf(addressIndex, message)
+	if rf, ok := ret.Get(1).(func(int, string, CoinType) error); ok {
+		r1 = rf(addressIndex, message, coinType)
 	} else {
 		r1 = ret.Error(1)
 	}
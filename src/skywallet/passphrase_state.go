@@ -0,0 +1,139 @@
+package skywallet
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// WalletSwapError is returned if the device reports another passphrase state than the
+// one remembered for it, meaning the passphrase entered opened another hidden wallet
+// than the one used so far. The operation is cancelled on the device.
+type WalletSwapError struct {
+	DeviceID string
+	// Expected is the state remembered for the device, Actual the state reported
+	Expected []byte
+	Actual   []byte
+}
+
+func (e WalletSwapError) Error() string {
+	return fmt.Sprintf("device %q opened another hidden wallet: passphrase state %x, expected %x", e.DeviceID, e.Actual, e.Expected)
+}
+
+// passphraseStates remembers the passphrase state of each device, by DeviceId
+type passphraseStates struct {
+	mu sync.Mutex
+	// deviceID is the DeviceId of the device last reported by its features
+	deviceID string
+	states   map[string][]byte
+}
+
+// setDeviceID records the DeviceId of the device the next states belong to
+func (p *passphraseStates) setDeviceID(deviceID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deviceID = deviceID
+}
+
+// check remembers state for the current device if it has none
+// and returns a WalletSwapError if it has another one.
+// Nothing is tracked while the DeviceId is unknown, as the states of
+// different devices could not be told apart.
+func (p *passphraseStates) check(state []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.deviceID == "" {
+		return nil
+	}
+	expected, ok := p.states[p.deviceID]
+	if !ok {
+		if p.states == nil {
+			p.states = make(map[string][]byte)
+		}
+		p.states[p.deviceID] = append([]byte{}, state...)
+		return nil
+	}
+	if !bytes.Equal(expected, state) {
+		return WalletSwapError{
+			DeviceID: p.deviceID,
+			Expected: append([]byte{}, expected...),
+			Actual:   append([]byte{}, state...),
+		}
+	}
+	return nil
+}
+
+// current returns the state remembered for the current device,
+// nil if its DeviceId is unknown
+func (p *passphraseStates) current() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.deviceID == "" {
+		return nil
+	}
+	return p.states[p.deviceID]
+}
+
+func (p *passphraseStates) get(deviceID string) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]byte(nil), p.states[deviceID]...)
+}
+
+func (p *passphraseStates) forget(deviceID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.states, deviceID)
+}
+
+// PassphraseState returns the passphrase state remembered for the device with deviceID,
+// nil if no passphrase was entered on it yet
+func (d *Device) PassphraseState(deviceID string) []byte {
+	return d.passphraseStates.get(deviceID)
+}
+
+// ForgetPassphraseState forgets the passphrase state of the device with deviceID,
+// so that another hidden wallet can be opened on it without a WalletSwapError
+func (d *Device) ForgetPassphraseState(deviceID string) {
+	d.passphraseStates.forget(deviceID)
+}
+
+// answerPassphraseState answers the PassphraseStateRequest sent after a passphrase
+// and returns the next message. The state is remembered for the device last
+// reported by GetFeatures, not at all if GetFeatures was never called, and a WalletSwapError is returned if it differs
+// from the state remembered before. The remembered state is sent with the
+// passphrase, so the device derives the new state with the same salt and
+// both only differ if the passphrase does.
+// Any other message is returned as is.
+func (d *Device) answerPassphraseState(msg wire.Message) (wire.Message, error) {
+	if msg.Kind != uint16(messages.MessageType_MessageType_PassphraseStateRequest) {
+		return msg, nil
+	}
+
+	request := &messages.PassphraseStateRequest{}
+	if err := decodeAs(msg, request); err != nil {
+		return wire.Message{}, err
+	}
+
+	if err := d.passphraseStates.check(request.State); err != nil {
+		cancelChunks, cancelErr := MessageCancel()
+		if cancelErr == nil {
+			_, cancelErr = d.Driver.SendToDevice(d.dev, cancelChunks)
+		}
+		if cancelErr != nil {
+			log.Errorf("failed to cancel after a wallet swap: %v", cancelErr)
+		}
+		return wire.Message{}, err
+	}
+
+	stateAckChunks, err := MessagePassphraseStateAck()
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return d.Driver.SendToDevice(d.dev, stateAckChunks)
+}
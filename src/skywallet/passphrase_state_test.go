@@ -0,0 +1,147 @@
+package skywallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestPassphraseState(t *testing.T) {
+	features, err := Encode(&messages.Features{DeviceId: proto.String("ABCD")})
+	require.NoError(t, err)
+	success, err := Encode(&messages.Success{})
+	require.NoError(t, err)
+	failure, err := Encode(&messages.Failure{Message: proto.String("Action cancelled by user")})
+	require.NoError(t, err)
+
+	stateRequest := func(state []byte) wire.Message {
+		msg, err := Encode(&messages.PassphraseStateRequest{State: state})
+		require.NoError(t, err)
+		return msg
+	}
+	// sent decodes the message sent in chunks
+	sent := func(chunks [][64]byte) proto.Message {
+		var buf bytes.Buffer
+		for _, c := range chunks {
+			buf.Write(c[:])
+		}
+		msg, err := wire.ReadFrom(&buf)
+		require.NoError(t, err)
+		m, err := Decode(*msg)
+		require.NoError(t, err)
+		return m
+	}
+
+	var ack *messages.PassphraseAck
+	// readAck records the PassphraseAck sent and answers it with state
+	readAck := func(state []byte) func(usb.Device, [][64]byte) wire.Message {
+		return func(_ usb.Device, chunks [][64]byte) wire.Message {
+			ack = sent(chunks).(*messages.PassphraseAck)
+			return stateRequest(state)
+		}
+	}
+	// expect checks the message sent is m and answers it with answer
+	expect := func(m proto.Message, answer wire.Message) func(usb.Device, [][64]byte) wire.Message {
+		return func(_ usb.Device, chunks [][64]byte) wire.Message {
+			require.Equal(t, m, sent(chunks))
+			return answer
+		}
+	}
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	device := getMockDevice(driverMock)
+
+	// the first passphrase state is remembered for the device
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(features, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt1wallet1")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.PassphraseStateAck{}, success), nil).Once()
+	_, err = device.GetFeatures()
+	require.NoError(t, err)
+	msg, err := device.PassphraseAck("secret")
+	require.NoError(t, err)
+	require.Equal(t, success, msg)
+	require.Nil(t, ack.State)
+	require.Equal(t, []byte("salt1wallet1"), device.PassphraseState("ABCD"))
+
+	// the same wallet is opened again with the remembered state
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt1wallet1")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.PassphraseStateAck{}, success), nil).Once()
+	_, err = device.PassphraseAck("secret")
+	require.NoError(t, err)
+	require.Equal(t, []byte("salt1wallet1"), ack.State)
+
+	// another wallet cancels the operation
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt1wallet2")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.Cancel{}, failure), nil).Once()
+	_, err = device.PassphraseAck("other")
+	require.Equal(t, WalletSwapError{
+		DeviceID: "ABCD",
+		Expected: []byte("salt1wallet1"),
+		Actual:   []byte("salt1wallet2"),
+	}, err)
+
+	// until the state is forgotten
+	device.ForgetPassphraseState("ABCD")
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt2wallet2")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.PassphraseStateAck{}, success), nil).Once()
+	_, err = device.PassphraseAck("other")
+	require.NoError(t, err)
+	require.Nil(t, ack.State)
+	require.Equal(t, []byte("salt2wallet2"), device.PassphraseState("ABCD"))
+
+	driverMock.AssertExpectations(t)
+}
+
+func TestPassphraseStateUnknownDevice(t *testing.T) {
+	success, err := Encode(&messages.Success{})
+	require.NoError(t, err)
+
+	var acks []*messages.PassphraseAck
+	// readAck records the PassphraseAck sent and answers it with state
+	readAck := func(state []byte) func(usb.Device, [][64]byte) wire.Message {
+		return func(_ usb.Device, chunks [][64]byte) wire.Message {
+			var buf bytes.Buffer
+			for _, c := range chunks {
+				buf.Write(c[:])
+			}
+			msg, err := wire.ReadFrom(&buf)
+			require.NoError(t, err)
+			m, err := Decode(*msg)
+			require.NoError(t, err)
+			acks = append(acks, m.(*messages.PassphraseAck))
+			answer, err := Encode(&messages.PassphraseStateRequest{State: state})
+			require.NoError(t, err)
+			return answer
+		}
+	}
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	device := getMockDevice(driverMock)
+
+	// without features two devices, or two wallets, cannot be told apart,
+	// so no state is remembered, sent or compared
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt1wallet1")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(success, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readAck([]byte("salt2wallet2")), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(success, nil).Once()
+	for _, passphrase := range []string{"secret", "other"} {
+		msg, err := device.PassphraseAck(passphrase)
+		require.NoError(t, err)
+		require.Equal(t, success, msg)
+	}
+	require.Len(t, acks, 2)
+	require.Nil(t, acks[0].State)
+	require.Nil(t, acks[1].State)
+	require.Nil(t, device.PassphraseState(""))
+
+	driverMock.AssertExpectations(t)
+}
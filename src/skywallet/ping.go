@@ -0,0 +1,124 @@
+package skywallet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+const pingNonceSize = 8
+
+var (
+	// ErrPingMismatch is returned if the device does not echo the ping message
+	ErrPingMismatch = errors.New("device answered the ping with another message")
+)
+
+// PingOptions selects the flows the device runs before answering a ping
+type PingOptions struct {
+	// ButtonProtection makes the device wait for a button press
+	ButtonProtection bool
+	// PinProtection makes the device ask for the PIN code, if one is set
+	PinProtection bool
+	// PassphraseProtection makes the device ask for the passphrase, if enabled
+	PassphraseProtection bool
+	// ReadPin and ReadPassphrase are called when the device asks for them
+	ReadPin        func() (string, error)
+	ReadPassphrase func() (string, error)
+}
+
+// PingResult is the outcome of a ping answered by the device
+type PingResult struct {
+	// Message sent and echoed by the device, ending with the nonce
+	Message string `json:"message"`
+	// Latency is the time the device took to send its first answer
+	Latency time.Duration `json:"latency"`
+	// Duration is the time until the device echoed the message,
+	// including any button press, PIN or passphrase entry
+	Duration time.Duration `json:"duration"`
+}
+
+// Ping sends text followed by a random nonce and checks the device echoes it back,
+// answering the button, PIN and passphrase requests enabled by opts.
+// ErrPingMismatch is returned if the device echoes another message.
+// If ctx is done before the device answers, ctx.Err() is returned while the pending
+// exchange completes in the background, so the device should be closed before reusing it.
+func (d *Device) Ping(ctx context.Context, text string, opts PingOptions) (*PingResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, pingNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	message := hex.EncodeToString(nonce)
+	if text != "" {
+		message = text + " " + message
+	}
+
+	type answer struct {
+		result *PingResult
+		err    error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		result, err := d.ping(message, opts)
+		done <- answer{result, err}
+	}()
+
+	select {
+	case a := <-done:
+		return a.result, a.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ping sends message and waits for the device to echo it
+func (d *Device) ping(message string, opts PingOptions) (*PingResult, error) {
+	start := time.Now()
+	msg, err := d.sendPing(message, opts)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+
+	msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_Success, opts.ReadPin, opts.ReadPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	echo, err := DecodeSuccessMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+	if echo != message {
+		return nil, ErrPingMismatch
+	}
+
+	return &PingResult{
+		Message:  message,
+		Latency:  latency,
+		Duration: time.Since(start),
+	}, nil
+}
+
+// sendPing sends the Ping message and returns the first answer of the device
+func (d *Device) sendPing(message string, opts PingOptions) (wire.Message, error) {
+	if err := d.Connect(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	pingChunks, err := MessagePing(message, opts.ButtonProtection, opts.PinProtection, opts.PassphraseProtection)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.Driver.SendToDevice(d.dev, pingChunks)
+}
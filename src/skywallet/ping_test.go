@@ -0,0 +1,90 @@
+package skywallet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestPing(t *testing.T) {
+	passphraseRequest, err := Encode(&messages.PassphraseRequest{})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		suffix string
+		err    error
+	}{
+		{
+			name: "echoed",
+		},
+		{
+			name:   "other message",
+			suffix: "!",
+			err:    ErrPingMismatch,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ping := &messages.Ping{}
+			// readPing decodes the Ping and asks for the passphrase
+			readPing := func(_ usb.Device, chunks [][64]byte) wire.Message {
+				var buf bytes.Buffer
+				for _, c := range chunks {
+					buf.Write(c[:])
+				}
+				msg, err := wire.ReadFrom(&buf)
+				require.NoError(t, err)
+				require.NoError(t, decodeAs(*msg, ping))
+				return passphraseRequest
+			}
+			// echo answers the passphrase with the ping message
+			echo := func(usb.Device, [][64]byte) wire.Message {
+				success, err := Encode(&messages.Success{Message: proto.String(ping.GetMessage() + tc.suffix)})
+				require.NoError(t, err)
+				return success
+			}
+
+			driverMock := &MockDeviceDriver{}
+			driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readPing, nil).Once()
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(echo, nil).Once()
+			device := getMockDevice(driverMock)
+
+			result, err := device.Ping(context.Background(), "hello", PingOptions{
+				PassphraseProtection: true,
+				ReadPassphrase: func() (string, error) {
+					return "secret", nil
+				},
+			})
+			require.True(t, ping.GetPassphraseProtection())
+			require.False(t, ping.GetButtonProtection())
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Regexp(t, "^hello [0-9a-f]{16}$", result.Message)
+			require.Equal(t, ping.GetMessage(), result.Message)
+			require.True(t, result.Duration >= result.Latency)
+		})
+	}
+}
+
+func TestPingCancelled(t *testing.T) {
+	device := getMockDevice(&MockDeviceDriver{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := device.Ping(ctx, "", PingOptions{})
+	require.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,162 @@
+package skywallet
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// redacted replaces the value of a secret field in the logs
+const redacted = "[redacted]"
+
+// secretFields are the json names of the message fields never logged: the mnemonic,
+// PIN, passphrase and recovery words typed by the user, the entropy mixed into the
+// seed, the keys of a loaded node and the passphrase state identifying a hidden wallet.
+// A field with one of these names is redacted wherever it is logged.
+var secretFields = map[string]bool{
+	"mnemonic":    true,
+	"pin":         true,
+	"passphrase":  true,
+	"word":        true,
+	"entropy":     true,
+	"private_key": true,
+	"chain_code":  true,
+	"state":       true,
+}
+
+// omittedFields are too large to be logged, only their presence is
+var omittedFields = map[string]bool{
+	"payload": true,
+}
+
+func init() {
+	logging.AddHook(redactHook{})
+}
+
+// redactHook redacts the secret fields of every log entry, whatever logged them
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		entry.Data[key] = redactField(key, value)
+	}
+	return nil
+}
+
+// redactField returns value, redacted if key names a secret field
+func redactField(key string, value interface{}) interface{} {
+	switch {
+	case secretFields[key]:
+		return redacted
+	case omittedFields[key]:
+		return "[omitted]"
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for k, field := range v {
+			fields[k] = redactField(k, field)
+		}
+		return fields
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = redactField("", item)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// MessageFields returns the fields of msg by their json name with the secret fields redacted
+func MessageFields(msg wire.Message) (map[string]interface{}, error) {
+	m, err := Decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return redactField("", fields).(map[string]interface{}), nil
+}
+
+// debugEnabled tells whether debug logs are written. The skycoin master logger
+// defaults to the debug level, so library users see every protocol message
+// unless they set a higher level with logging.SetLevel.
+func debugEnabled() bool {
+	entry, ok := log.FieldLogger.(*logrus.Entry)
+	return !ok || entry.Logger.IsLevelEnabled(logrus.DebugLevel)
+}
+
+// logMessage logs msg sent to or received from the device at debug level,
+// it is only decoded if debug logs are enabled
+func logMessage(direction string, msg wire.Message) {
+	if !debugEnabled() {
+		return
+	}
+	entry := log.WithField("direction", direction).
+		WithField("type", MessageTypeName(messages.MessageType(msg.Kind))).
+		WithField("size", len(msg.Data))
+	fields, err := MessageFields(msg)
+	if err != nil {
+		entry = entry.WithField("decode_error", err.Error())
+	} else {
+		entry = entry.WithField("fields", fields)
+	}
+	entry.Debug("protocol message")
+}
+
+// logPackets logs the message made of chunks
+func logPackets(chunks [][64]byte) {
+	if !debugEnabled() {
+		return
+	}
+	var b bytes.Buffer
+	for _, chunk := range chunks {
+		b.Write(chunk[:])
+	}
+	msg, err := wire.ReadFrom(&b)
+	if err != nil {
+		log.WithField("direction", "sent").Debugf("protocol message: %v", err)
+		return
+	}
+	logMessage("sent", *msg)
+}
+
+// writePackets logs and writes the message made of chunks to dev
+func writePackets(dev usb.Device, chunks [][64]byte) error {
+	logPackets(chunks)
+	for _, element := range chunks {
+		if _, err := dev.Write(element[:]); err != nil {
+			return TransportError{Err: err}
+		}
+	}
+	return nil
+}
+
+// readMessage reads and logs a message from dev
+func readMessage(dev usb.Device) (*wire.Message, error) {
+	msg, err := wire.ReadFrom(dev)
+	if err != nil {
+		return nil, readError(err)
+	}
+	logMessage("received", *msg)
+	return msg, nil
+}
@@ -0,0 +1,119 @@
+package skywallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageFields(t *testing.T) {
+	tt := []struct {
+		name     string
+		msg      proto.Message
+		expected map[string]interface{}
+	}{
+		{
+			name:     "SetMnemonic",
+			msg:      &messages.SetMnemonic{Mnemonic: proto.String("cloud flower upset remain green metal below cup stem infant art thank")},
+			expected: map[string]interface{}{"mnemonic": redacted},
+		},
+		{
+			name:     "PinMatrixAck",
+			msg:      &messages.PinMatrixAck{Pin: proto.String("1234")},
+			expected: map[string]interface{}{"pin": redacted},
+		},
+		{
+			name:     "PassphraseAck",
+			msg:      &messages.PassphraseAck{Passphrase: proto.String("hidden"), State: []byte{1, 2}},
+			expected: map[string]interface{}{"passphrase": redacted, "state": redacted},
+		},
+		{
+			name:     "WordAck",
+			msg:      &messages.WordAck{Word: proto.String("cloud")},
+			expected: map[string]interface{}{"word": redacted},
+		},
+		{
+			name:     "EntropyAck",
+			msg:      &messages.EntropyAck{Entropy: []byte{1, 2, 3}},
+			expected: map[string]interface{}{"entropy": redacted},
+		},
+		{
+			name: "LoadDevice",
+			msg: &messages.LoadDevice{
+				Pin:   proto.String("1234"),
+				Label: proto.String("my wallet"),
+				Node: &messages.HDNodeType{
+					Depth:       proto.Uint32(0),
+					Fingerprint: proto.Uint32(0),
+					ChildNum:    proto.Uint32(0),
+					ChainCode:   []byte{1},
+					PrivateKey:  []byte{2},
+					PublicKey:   []byte{3},
+				},
+			},
+			expected: map[string]interface{}{
+				"pin":   redacted,
+				"label": "my wallet",
+				"node": map[string]interface{}{
+					"depth":       float64(0),
+					"fingerprint": float64(0),
+					"child_num":   float64(0),
+					"chain_code":  redacted,
+					"private_key": redacted,
+					"public_key":  "Aw==",
+				},
+			},
+		},
+		{
+			name:     "FirmwareUpload",
+			msg:      &messages.FirmwareUpload{Payload: make([]byte, 1024)},
+			expected: map[string]interface{}{"payload": "[omitted]"},
+		},
+		{
+			name:     "SkycoinAddress",
+			msg:      &messages.SkycoinAddress{AddressN: proto.Uint32(2), StartIndex: proto.Uint32(1)},
+			expected: map[string]interface{}{"address_n": float64(2), "start_index": float64(1)},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := Encode(tc.msg)
+			require.NoError(t, err)
+			fields, err := MessageFields(msg)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, fields)
+		})
+	}
+}
+
+func TestRedactHook(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.AddHook(redactHook{})
+
+	logger.WithField("pin", "1234").
+		WithField("fields", map[string]interface{}{"mnemonic": "cloud flower", "label": "my wallet"}).
+		Info("setting pin")
+
+	require.NotContains(t, out.String(), "1234")
+	require.NotContains(t, out.String(), "cloud flower")
+	require.Contains(t, out.String(), `"pin":"[redacted]"`)
+	require.Contains(t, out.String(), `"label":"my wallet"`)
+}
+
+func TestDebugEnabled(t *testing.T) {
+	defer logging.SetLevel(log.FieldLogger.(*logrus.Entry).Logger.GetLevel())
+
+	logging.SetLevel(logrus.DebugLevel)
+	require.True(t, debugEnabled())
+	logging.SetLevel(logrus.InfoLevel)
+	require.False(t, debugEnabled())
+}
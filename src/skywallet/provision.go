@@ -0,0 +1,286 @@
+package skywallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"gopkg.in/yaml.v3"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// SeedSource tells how the seed of a provisioned device is created
+type SeedSource string
+
+const (
+	// SeedSourceGenerate the device generates its own mnemonic
+	SeedSourceGenerate SeedSource = "generate"
+	// SeedSourceImport the profile mnemonic is loaded in the device
+	SeedSourceImport SeedSource = "import"
+)
+
+// PinPolicy tells whether a provisioned device must be protected by a PIN code
+type PinPolicy string
+
+const (
+	// PinPolicyKeep leave the device PIN code as it is
+	PinPolicyKeep PinPolicy = ""
+	// PinPolicyRequired the device must have a PIN code
+	PinPolicyRequired PinPolicy = "required"
+	// PinPolicyNone the device must not have a PIN code
+	PinPolicyNone PinPolicy = "none"
+)
+
+var (
+	// ErrInvalidSeedSource is returned if the profile seed source is unknown
+	ErrInvalidSeedSource = errors.New("seed must be generate or import")
+	// ErrInvalidPinPolicy is returned if the profile PIN policy is unknown
+	ErrInvalidPinPolicy = errors.New("pin_policy must be required, none or empty")
+	// ErrMnemonicRequired is returned if the profile imports a seed without giving a mnemonic
+	ErrMnemonicRequired = errors.New("mnemonic is required to import a seed")
+	// ErrUnknownProfileFormat is returned if the profile file is neither json nor yaml
+	ErrUnknownProfileFormat = errors.New("profile file extension must be .json, .yaml or .yml")
+)
+
+// ProvisionProfile describes the configuration a device should have.
+// Empty fields leave the matching device setting unchanged.
+type ProvisionProfile struct {
+	Label                string     `json:"label,omitempty" yaml:"label,omitempty"`
+	Language             string     `json:"language,omitempty" yaml:"language,omitempty"`
+	PassphraseProtection *bool      `json:"passphrase_protection,omitempty" yaml:"passphrase_protection,omitempty"`
+	PinPolicy            PinPolicy  `json:"pin_policy,omitempty" yaml:"pin_policy,omitempty"`
+	WordCount            uint32     `json:"word_count,omitempty" yaml:"word_count,omitempty"`
+	Seed                 SeedSource `json:"seed" yaml:"seed"`
+	Mnemonic             string     `json:"mnemonic,omitempty" yaml:"mnemonic,omitempty"`
+	// Backup a generated seed if the device reports it needs backup
+	Backup bool `json:"backup,omitempty" yaml:"backup,omitempty"`
+}
+
+// LoadProvisionProfile reads a profile from a json or yaml file.
+// Unknown fields are rejected, so that a misspelled setting is not silently left unchanged.
+func LoadProvisionProfile(path string) (*ProvisionProfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile ProvisionProfile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&profile)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&profile)
+	default:
+		return nil, ErrUnknownProfileFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Validate checks the profile fields are consistent
+func (p ProvisionProfile) Validate() error {
+	switch p.Seed {
+	case SeedSourceGenerate:
+		if p.WordCount != 12 && p.WordCount != 24 {
+			return ErrInvalidWordCount
+		}
+	case SeedSourceImport:
+		if p.Mnemonic == "" {
+			return ErrMnemonicRequired
+		}
+		if err := bip39.ValidateMnemonic(p.Mnemonic); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidSeedSource
+	}
+
+	switch p.PinPolicy {
+	case PinPolicyKeep, PinPolicyRequired, PinPolicyNone:
+	default:
+		return ErrInvalidPinPolicy
+	}
+	return nil
+}
+
+// ProvisionStepStatus is the outcome of a provisioning step
+type ProvisionStepStatus string
+
+const (
+	// ProvisionStepApplied the step changed the device configuration
+	ProvisionStepApplied ProvisionStepStatus = "applied"
+	// ProvisionStepSkipped the device already matched the profile
+	ProvisionStepSkipped ProvisionStepStatus = "skipped"
+	// ProvisionStepFailed the step could not be applied
+	ProvisionStepFailed ProvisionStepStatus = "failed"
+)
+
+// ProvisionStepResult reports what happened with a provisioning step
+type ProvisionStepResult struct {
+	Step    string              `json:"step"`
+	Status  ProvisionStepStatus `json:"status"`
+	Message string              `json:"message,omitempty"`
+}
+
+// ProvisionReport reports the provisioning of a device
+type ProvisionReport struct {
+	DeviceID string                `json:"device_id"`
+	Label    string                `json:"label"`
+	Steps    []ProvisionStepResult `json:"steps"`
+}
+
+// provisionStep is a change that brings the device closer to the profile.
+// needed tells from the current device features if the step must be applied.
+type provisionStep struct {
+	name   string
+	needed func(p ProvisionProfile, f *messages.Features) bool
+	apply  func(d *Device, p ProvisionProfile, f *messages.Features) (wire.Message, error)
+}
+
+// wipeStep wipes an initialized device, it is only run when asked for since it destroys the seed
+var wipeStep = provisionStep{
+	name: "wipe",
+	needed: func(_ ProvisionProfile, f *messages.Features) bool {
+		return f.GetInitialized()
+	},
+	apply: func(d *Device, _ ProvisionProfile, _ *messages.Features) (wire.Message, error) {
+		return d.Wipe()
+	},
+}
+
+// provisionSteps lists the provisioning steps in the order they are applied
+var provisionSteps = []provisionStep{
+	{
+		name: "seed",
+		needed: func(_ ProvisionProfile, f *messages.Features) bool {
+			return !f.GetInitialized()
+		},
+		apply: func(d *Device, p ProvisionProfile, _ *messages.Features) (wire.Message, error) {
+			if p.Seed == SeedSourceImport {
+				return d.SetMnemonic(p.Mnemonic)
+			}
+			usePassphrase := p.PassphraseProtection != nil && *p.PassphraseProtection
+			return d.GenerateMnemonic(p.WordCount, usePassphrase)
+		},
+	},
+	{
+		name: "settings",
+		needed: func(p ProvisionProfile, f *messages.Features) bool {
+			return (p.Label != "" && p.Label != f.GetLabel()) ||
+				(p.Language != "" && p.Language != f.GetLanguage()) ||
+				(p.PassphraseProtection != nil && *p.PassphraseProtection != f.GetPassphraseProtection())
+		},
+		apply: func(d *Device, p ProvisionProfile, f *messages.Features) (wire.Message, error) {
+			label := p.Label
+			if label == "" {
+				label = f.GetLabel()
+			}
+			language := p.Language
+			if language == "" {
+				language = f.GetLanguage()
+			}
+			return d.ApplySettings(p.PassphraseProtection, label, language)
+		},
+	},
+	{
+		name: "pin",
+		needed: func(p ProvisionProfile, f *messages.Features) bool {
+			return (p.PinPolicy == PinPolicyRequired && !f.GetPinProtection()) ||
+				(p.PinPolicy == PinPolicyNone && f.GetPinProtection())
+		},
+		apply: func(d *Device, p ProvisionProfile, _ *messages.Features) (wire.Message, error) {
+			removePin := p.PinPolicy == PinPolicyNone
+			return d.ChangePin(&removePin)
+		},
+	},
+	{
+		name: "backup",
+		needed: func(p ProvisionProfile, f *messages.Features) bool {
+			return p.Backup && f.GetNeedsBackup()
+		},
+		apply: func(d *Device, _ ProvisionProfile, _ *messages.Features) (wire.Message, error) {
+			return d.Backup()
+		},
+	},
+}
+
+// Provision brings the device configuration to match the profile.
+// Device features are read before each step so only the settings that differ
+// from the profile are changed, and provisioning the same device twice is a no op.
+// An initialized device keeps its seed unless wipe is set, in which case it is wiped
+// first and provisioned again from scratch.
+// readPin is called each time the device asks for a PIN code.
+// The report is returned even if a step fails.
+func (d *Device) Provision(profile ProvisionProfile, wipe bool, readPin func() (string, error)) (*ProvisionReport, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	steps := provisionSteps
+	if wipe {
+		steps = append([]provisionStep{wipeStep}, provisionSteps...)
+	}
+
+	report := &ProvisionReport{}
+	for _, step := range steps {
+		features, err := d.features()
+		if err != nil {
+			return report, err
+		}
+		report.DeviceID = features.GetDeviceId()
+		report.Label = features.GetLabel()
+
+		if !step.needed(profile, features) {
+			report.Steps = append(report.Steps, ProvisionStepResult{Step: step.name, Status: ProvisionStepSkipped})
+			continue
+		}
+
+		msg, err := step.apply(d, profile, features)
+		if err == nil {
+			msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_Success, readPin, nil)
+		}
+		if err != nil {
+			report.Steps = append(report.Steps, ProvisionStepResult{
+				Step:    step.name,
+				Status:  ProvisionStepFailed,
+				Message: err.Error(),
+			})
+			return report, fmt.Errorf("provisioning step %s failed: %w", step.name, err)
+		}
+
+		successMsg, err := DecodeSuccessMsg(msg)
+		if err != nil {
+			return report, err
+		}
+		report.Steps = append(report.Steps, ProvisionStepResult{
+			Step:    step.name,
+			Status:  ProvisionStepApplied,
+			Message: successMsg,
+		})
+	}
+
+	features, err := d.features()
+	if err != nil {
+		return report, err
+	}
+	report.DeviceID = features.GetDeviceId()
+	report.Label = features.GetLabel()
+	return report, nil
+}
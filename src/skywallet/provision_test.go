@@ -0,0 +1,321 @@
+package skywallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+type provisionSuit struct {
+	suite.Suite
+}
+
+func TestProvisionSuit(t *testing.T) {
+	suite.Run(t, new(provisionSuit))
+}
+
+func (suite *provisionSuit) TestLoadProvisionProfile() {
+	dir, err := ioutil.TempDir("", "provision")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{
+			name:    "profile.json",
+			content: `{"label": "treasury", "passphrase_protection": true, "pin_policy": "required", "seed": "generate", "word_count": 24, "backup": true}`,
+		},
+		{
+			name: "profile.yaml",
+			content: `label: treasury
+passphrase_protection: true
+pin_policy: required
+seed: generate
+word_count: 24
+backup: true
+`,
+		},
+		{
+			name:    "profile.txt",
+			content: `label: treasury`,
+			err:     ErrUnknownProfileFormat,
+		},
+		{
+			name:    "invalid.yml",
+			content: `seed: clone`,
+			err:     ErrInvalidSeedSource,
+		},
+	}
+
+	// unknown fields, i.e. the wipe option that is a command flag instead, are rejected
+	for name, content := range map[string]string{
+		"unknown.json": `{"seed": "generate", "word_count": 12, "wipe": true}`,
+		"unknown.yaml": "seed: generate\nword_count: 12\nwipe: true\n",
+	} {
+		path := filepath.Join(dir, name)
+		suite.Require().NoError(ioutil.WriteFile(path, []byte(content), 0600))
+		_, err := LoadProvisionProfile(path)
+		suite.Error(err, name)
+	}
+
+	for _, tc := range tt {
+		path := filepath.Join(dir, tc.name)
+		suite.Require().NoError(ioutil.WriteFile(path, []byte(tc.content), 0600))
+
+		profile, err := LoadProvisionProfile(path)
+		suite.Equal(tc.err, err, tc.name)
+		if tc.err != nil {
+			continue
+		}
+		suite.Equal(ProvisionProfile{
+			Label:                "treasury",
+			PassphraseProtection: proto.Bool(true),
+			PinPolicy:            PinPolicyRequired,
+			Seed:                 SeedSourceGenerate,
+			WordCount:            24,
+			Backup:               true,
+		}, *profile, tc.name)
+	}
+}
+
+func (suite *provisionSuit) TestValidate() {
+	tt := []struct {
+		name    string
+		profile ProvisionProfile
+		err     error
+	}{
+		{
+			name:    "generate",
+			profile: ProvisionProfile{Seed: SeedSourceGenerate, WordCount: 12},
+		},
+		{
+			name:    "generate wrong word count",
+			profile: ProvisionProfile{Seed: SeedSourceGenerate, WordCount: 18},
+			err:     ErrInvalidWordCount,
+		},
+		{
+			name:    "import",
+			profile: ProvisionProfile{Seed: SeedSourceImport, Mnemonic: "cloud flower upset remain green metal below cup stem infant art thank"},
+		},
+		{
+			name:    "import without mnemonic",
+			profile: ProvisionProfile{Seed: SeedSourceImport},
+			err:     ErrMnemonicRequired,
+		},
+		{
+			name:    "import invalid mnemonic",
+			profile: ProvisionProfile{Seed: SeedSourceImport, Mnemonic: "cloud flower upset remain green metal below cup stem infant art art"},
+			err:     bip39.ErrChecksumMismatch,
+		},
+		{
+			name:    "invalid pin policy",
+			profile: ProvisionProfile{Seed: SeedSourceGenerate, WordCount: 12, PinPolicy: "sometimes"},
+			err:     ErrInvalidPinPolicy,
+		},
+	}
+
+	for _, tc := range tt {
+		suite.Equal(tc.err, tc.profile.Validate(), tc.name)
+	}
+}
+
+func (suite *provisionSuit) TestProvisionStepsNeeded() {
+	profile := ProvisionProfile{
+		Label:                "treasury",
+		PassphraseProtection: proto.Bool(true),
+		PinPolicy:            PinPolicyRequired,
+		Seed:                 SeedSourceGenerate,
+		WordCount:            12,
+		Backup:               true,
+	}
+
+	tt := []struct {
+		name     string
+		features *messages.Features
+		needed   []string
+	}{
+		{
+			name:     "new device",
+			features: &messages.Features{Initialized: proto.Bool(false)},
+			needed:   []string{"seed", "settings", "pin"},
+		},
+		{
+			name: "provisioned device",
+			features: &messages.Features{
+				Initialized:          proto.Bool(true),
+				Label:                proto.String("treasury"),
+				PassphraseProtection: proto.Bool(true),
+				PinProtection:        proto.Bool(true),
+			},
+		},
+		{
+			name: "label and backup differ",
+			features: &messages.Features{
+				Initialized:          proto.Bool(true),
+				Label:                proto.String("savings"),
+				PassphraseProtection: proto.Bool(true),
+				PinProtection:        proto.Bool(true),
+				NeedsBackup:          proto.Bool(true),
+			},
+			needed: []string{"settings", "backup"},
+		},
+	}
+
+	for _, tc := range tt {
+		var needed []string
+		for _, step := range provisionSteps {
+			if step.needed(profile, tc.features) {
+				needed = append(needed, step.name)
+			}
+		}
+		suite.Equal(tc.needed, needed, tc.name)
+	}
+}
+
+func (suite *provisionSuit) TestProvision() {
+	// NOTE: Giving
+	before, err := proto.Marshal(&messages.Features{
+		DeviceId:    proto.String("ABCD"),
+		Initialized: proto.Bool(true),
+		Label:       proto.String("savings"),
+	})
+	suite.Require().NoError(err)
+	after, err := proto.Marshal(&messages.Features{
+		DeviceId:    proto.String("ABCD"),
+		Initialized: proto.Bool(true),
+		Label:       proto.String("treasury"),
+	})
+	suite.Require().NoError(err)
+	success, err := proto.Marshal(&messages.Success{Message: proto.String("Settings applied")})
+	suite.Require().NoError(err)
+
+	featuresMsg := func(data []byte) wire.Message {
+		return wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: data}
+	}
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	// seed and settings steps
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(featuresMsg(before), nil).Times(2)
+	// apply settings
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Success), Data: success}, nil).Once()
+	// pin and backup steps, final report
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(featuresMsg(after), nil).Times(3)
+	device := getMockDevice(driverMock)
+
+	// NOTE: When
+	report, err := device.Provision(ProvisionProfile{
+		Label:     "treasury",
+		Seed:      SeedSourceGenerate,
+		WordCount: 12,
+	}, false, nil)
+
+	// NOTE: Assert
+	suite.NoError(err)
+	suite.Equal(&ProvisionReport{
+		DeviceID: "ABCD",
+		Label:    "treasury",
+		Steps: []ProvisionStepResult{
+			{Step: "seed", Status: ProvisionStepSkipped},
+			{Step: "settings", Status: ProvisionStepApplied, Message: "Settings applied"},
+			{Step: "pin", Status: ProvisionStepSkipped},
+			{Step: "backup", Status: ProvisionStepSkipped},
+		},
+	}, report)
+	driverMock.AssertNumberOfCalls(suite.T(), "SendToDevice", 6)
+}
+
+// fakeProvisionedDevice answers the messages sent while provisioning
+// as a device whose features change with them
+type fakeProvisionedDevice struct {
+	features messages.Features
+	sent     []messages.MessageType
+}
+
+func (f *fakeProvisionedDevice) answer(_ usb.Device, chunks [][64]byte) wire.Message {
+	var b bytes.Buffer
+	for _, chunk := range chunks {
+		b.Write(chunk[:])
+	}
+	msg, err := wire.ReadFrom(&b)
+	if err != nil {
+		panic(err)
+	}
+	kind := messages.MessageType(msg.Kind)
+	f.sent = append(f.sent, kind)
+
+	switch kind {
+	case messages.MessageType_MessageType_GetFeatures:
+		data, err := proto.Marshal(&f.features)
+		if err != nil {
+			panic(err)
+		}
+		return wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: data}
+	case messages.MessageType_MessageType_WipeDevice:
+		f.features = messages.Features{DeviceId: f.features.DeviceId, Initialized: proto.Bool(false)}
+	case messages.MessageType_MessageType_SetMnemonic:
+		f.features.Initialized = proto.Bool(true)
+	case messages.MessageType_MessageType_ApplySettings:
+		var settings messages.ApplySettings
+		if err := proto.Unmarshal(msg.Data, &settings); err != nil {
+			panic(err)
+		}
+		f.features.Label = settings.Label
+	}
+	data, err := proto.Marshal(&messages.Success{Message: proto.String("done")})
+	if err != nil {
+		panic(err)
+	}
+	return wire.Message{Kind: uint16(messages.MessageType_MessageType_Success), Data: data}
+}
+
+func (suite *provisionSuit) TestProvisionTwice() {
+	// NOTE: Giving
+	fake := &fakeProvisionedDevice{features: messages.Features{
+		DeviceId:    proto.String("ABCD"),
+		Initialized: proto.Bool(true),
+		Label:       proto.String("savings"),
+	}}
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(fake.answer, nil)
+	device := getMockDevice(driverMock)
+	profile := ProvisionProfile{
+		Label:    "treasury",
+		Seed:     SeedSourceImport,
+		Mnemonic: "cloud flower upset remain green metal below cup stem infant art thank",
+	}
+
+	// NOTE: When
+	_, err := device.Provision(profile, true, nil)
+	suite.Require().NoError(err)
+	first := fake.sent
+	fake.sent = nil
+	report, err := device.Provision(profile, false, nil)
+
+	// NOTE: Assert
+	suite.NoError(err)
+	suite.Contains(first, messages.MessageType_MessageType_WipeDevice)
+	suite.Contains(first, messages.MessageType_MessageType_SetMnemonic)
+	// the provisioned device is left as it is
+	for _, kind := range fake.sent {
+		suite.Equal(messages.MessageType_MessageType_GetFeatures, kind)
+	}
+	for _, step := range report.Steps {
+		suite.Equal(ProvisionStepSkipped, step.Status, step.Step)
+	}
+}
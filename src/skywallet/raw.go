@@ -0,0 +1,180 @@
+package skywallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// messageTypePrefix prefixes the names of the MessageType values
+const messageTypePrefix = "MessageType_"
+
+// ParseMessageType returns the MessageType named name, i.e. Ping or MessageType_Ping
+func ParseMessageType(name string) (messages.MessageType, error) {
+	value, ok := messages.MessageType_value[messageTypePrefix+strings.TrimPrefix(name, messageTypePrefix)]
+	if !ok {
+		return 0, fmt.Errorf("unknown message type %q", name)
+	}
+	return messages.MessageType(value), nil
+}
+
+// MessageTypeName returns the name of kind without its MessageType_ prefix
+func MessageTypeName(kind messages.MessageType) string {
+	return strings.TrimPrefix(kind.String(), messageTypePrefix)
+}
+
+// ParseMessage builds a message of type kind from body. A body starting with
+// '{' is JSON using the protobuf field names, i.e. {"message": "hello"}, any
+// other body is in protobuf text format, i.e. message: "hello".
+// An empty body builds an empty message.
+func ParseMessage(kind messages.MessageType, body string) (proto.Message, error) {
+	m, err := NewMessage(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if strings.HasPrefix(body, "{") {
+		d := json.NewDecoder(strings.NewReader(body))
+		d.DisallowUnknownFields()
+		if err := d.Decode(m); err != nil {
+			return nil, fmt.Errorf("invalid %s body: %v", MessageTypeName(kind), err)
+		}
+		return m, nil
+	}
+	if err := proto.UnmarshalText(body, m); err != nil {
+		return nil, fmt.Errorf("invalid %s body: %v", MessageTypeName(kind), err)
+	}
+	return m, nil
+}
+
+// MessageJSON decodes msg and encodes it as a JSON object holding its type and body
+func MessageJSON(msg wire.Message) ([]byte, error) {
+	m, err := Decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Type string        `json:"type"`
+		Body proto.Message `json:"body"`
+	}{
+		Type: MessageTypeName(messages.MessageType(msg.Kind)),
+		Body: m,
+	})
+}
+
+// Call sends m to the device and returns its answer
+func (d *Device) Call(m proto.Message) (wire.Message, error) {
+	if err := d.Connect(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	chunks, err := encodePackets(m)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.Driver.SendToDevice(d.dev, chunks)
+}
+
+// Exchange sends m and answers the button, PIN, passphrase and word requests
+// following it until the device sends any other message, which is returned.
+// Every message received, requests included, is passed to handle first.
+// readPin, readPassphrase and readWord are called when the device asks for
+// them, a nil function makes the request fail.
+func (d *Device) Exchange(m proto.Message, handle func(msg wire.Message) error, readPin, readPassphrase, readWord func() (string, error)) (wire.Message, error) {
+	msg, err := d.Call(m)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	// answer runs read and sends its result with ack
+	answer := func(request string, read func() (string, error), ack func(string) (wire.Message, error)) (wire.Message, error) {
+		if read == nil {
+			return wire.Message{}, fmt.Errorf("device asked for a %s", request)
+		}
+		value, err := read()
+		if err != nil {
+			return wire.Message{}, err
+		}
+		return ack(value)
+	}
+
+	for {
+		if err := handle(msg); err != nil {
+			return wire.Message{}, err
+		}
+
+		switch msg.Kind {
+		case uint16(messages.MessageType_MessageType_ButtonRequest):
+			msg, err = d.ButtonAck()
+		case uint16(messages.MessageType_MessageType_PinMatrixRequest):
+			msg, err = answer("PIN code", readPin, d.PinMatrixAck)
+		case uint16(messages.MessageType_MessageType_PassphraseRequest):
+			msg, err = answer("passphrase", readPassphrase, d.PassphraseAck)
+		case uint16(messages.MessageType_MessageType_WordRequest):
+			msg, err = answer("word", readWord, d.WordAck)
+		default:
+			return msg, nil
+		}
+		if err != nil {
+			return wire.Message{}, err
+		}
+	}
+}
+
+// RawStep is a message of a raw exchange script
+type RawStep struct {
+	// Line of the script the step was read from
+	Line    int
+	Message proto.Message
+}
+
+// ParseRawScript reads a raw exchange script. Each line holds a message type
+// name followed by an optional body as accepted by ParseMessage, i.e.
+//
+//	# comments and blank lines are ignored
+//	Ping {"message": "hello", "button_protection": true}
+//	GetFeatures
+//	ApplySettings label: "treasury"
+func ParseRawScript(r io.Reader) ([]RawStep, error) {
+	var steps []RawStep
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, body := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			name, body = text[:i], text[i+1:]
+		}
+		kind, err := ParseMessageType(name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		m, err := ParseMessage(kind, body)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		steps = append(steps, RawStep{Line: line, Message: m})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("script has no message")
+	}
+	return steps, nil
+}
@@ -0,0 +1,116 @@
+package skywallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestParseMessageType(t *testing.T) {
+	kind, err := ParseMessageType("Ping")
+	require.NoError(t, err)
+	require.Equal(t, messages.MessageType_MessageType_Ping, kind)
+
+	kind, err = ParseMessageType("MessageType_PassphraseStateRequest")
+	require.NoError(t, err)
+	require.Equal(t, messages.MessageType_MessageType_PassphraseStateRequest, kind)
+	require.Equal(t, "PassphraseStateRequest", MessageTypeName(kind))
+
+	_, err = ParseMessageType("Pong")
+	require.EqualError(t, err, `unknown message type "Pong"`)
+}
+
+func TestParseMessage(t *testing.T) {
+	expected := &messages.Ping{
+		Message:          proto.String("hello"),
+		ButtonProtection: proto.Bool(true),
+	}
+
+	m, err := ParseMessage(messages.MessageType_MessageType_Ping, `{"message": "hello", "button_protection": true}`)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	m, err = ParseMessage(messages.MessageType_MessageType_Ping, `message: "hello" button_protection: true`)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	m, err = ParseMessage(messages.MessageType_MessageType_GetFeatures, "")
+	require.NoError(t, err)
+	require.Equal(t, &messages.GetFeatures{}, m)
+
+	_, err = ParseMessage(messages.MessageType_MessageType_Ping, `{"msg": "hello"}`)
+	require.Error(t, err)
+	_, err = ParseMessage(messages.MessageType_MessageType_Ping, `msg: "hello"`)
+	require.Error(t, err)
+}
+
+func TestMessageJSON(t *testing.T) {
+	msg, err := Encode(&messages.Failure{
+		Code:    messages.FailureType_Failure_ActionCancelled.Enum(),
+		Message: proto.String("Action cancelled by user"),
+	})
+	require.NoError(t, err)
+
+	b, err := MessageJSON(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "Failure", "body": {"code": 4, "message": "Action cancelled by user"}}`, string(b))
+}
+
+func TestParseRawScript(t *testing.T) {
+	steps, err := ParseRawScript(strings.NewReader(`
+# check the device answers
+Ping {"message": "hello"}
+
+GetFeatures
+ApplySettings label: "treasury"
+`))
+	require.NoError(t, err)
+	require.Equal(t, []RawStep{
+		{Line: 3, Message: &messages.Ping{Message: proto.String("hello")}},
+		{Line: 5, Message: &messages.GetFeatures{}},
+		{Line: 6, Message: &messages.ApplySettings{Label: proto.String("treasury")}},
+	}, steps)
+
+	_, err = ParseRawScript(strings.NewReader("Ping\nPong\n"))
+	require.EqualError(t, err, `line 2: unknown message type "Pong"`)
+
+	_, err = ParseRawScript(strings.NewReader("# nothing\n"))
+	require.Error(t, err)
+}
+
+func TestExchange(t *testing.T) {
+	passphraseRequest, err := Encode(&messages.PassphraseRequest{})
+	require.NoError(t, err)
+	success, err := Encode(&messages.Success{Message: proto.String("hello")})
+	require.NoError(t, err)
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(passphraseRequest, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(success, nil).Once()
+	device := getMockDevice(driverMock)
+
+	var received []uint16
+	handle := func(msg wire.Message) error {
+		received = append(received, msg.Kind)
+		return nil
+	}
+	readPassphrase := func() (string, error) {
+		return "secret", nil
+	}
+
+	msg, err := device.Exchange(&messages.Ping{Message: proto.String("hello"), PassphraseProtection: proto.Bool(true)}, handle, nil, readPassphrase, nil)
+	require.NoError(t, err)
+	require.Equal(t, success, msg)
+	require.Equal(t, []uint16{passphraseRequest.Kind, success.Kind}, received)
+
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(passphraseRequest, nil).Once()
+	_, err = device.Exchange(&messages.Ping{}, handle, nil, nil, nil)
+	require.EqualError(t, err, "device asked for a passphrase")
+}
@@ -0,0 +1,150 @@
+package skywallet
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// messageConstructors maps every MessageType to a constructor of its protobuf message.
+// A message type added to the protocol needs an entry here.
+var messageConstructors = map[messages.MessageType]func() proto.Message{
+	messages.MessageType_MessageType_Initialize:                   func() proto.Message { return &messages.Initialize{} },
+	messages.MessageType_MessageType_Ping:                         func() proto.Message { return &messages.Ping{} },
+	messages.MessageType_MessageType_Success:                      func() proto.Message { return &messages.Success{} },
+	messages.MessageType_MessageType_Failure:                      func() proto.Message { return &messages.Failure{} },
+	messages.MessageType_MessageType_ChangePin:                    func() proto.Message { return &messages.ChangePin{} },
+	messages.MessageType_MessageType_WipeDevice:                   func() proto.Message { return &messages.WipeDevice{} },
+	messages.MessageType_MessageType_FirmwareErase:                func() proto.Message { return &messages.FirmwareErase{} },
+	messages.MessageType_MessageType_FirmwareUpload:               func() proto.Message { return &messages.FirmwareUpload{} },
+	messages.MessageType_MessageType_GetRawEntropy:                func() proto.Message { return &messages.GetRawEntropy{} },
+	messages.MessageType_MessageType_Entropy:                      func() proto.Message { return &messages.Entropy{} },
+	messages.MessageType_MessageType_LoadDevice:                   func() proto.Message { return &messages.LoadDevice{} },
+	messages.MessageType_MessageType_ResetDevice:                  func() proto.Message { return &messages.ResetDevice{} },
+	messages.MessageType_MessageType_Features:                     func() proto.Message { return &messages.Features{} },
+	messages.MessageType_MessageType_PinMatrixRequest:             func() proto.Message { return &messages.PinMatrixRequest{} },
+	messages.MessageType_MessageType_PinMatrixAck:                 func() proto.Message { return &messages.PinMatrixAck{} },
+	messages.MessageType_MessageType_Cancel:                       func() proto.Message { return &messages.Cancel{} },
+	messages.MessageType_MessageType_ApplySettings:                func() proto.Message { return &messages.ApplySettings{} },
+	messages.MessageType_MessageType_ButtonRequest:                func() proto.Message { return &messages.ButtonRequest{} },
+	messages.MessageType_MessageType_ButtonAck:                    func() proto.Message { return &messages.ButtonAck{} },
+	messages.MessageType_MessageType_BackupDevice:                 func() proto.Message { return &messages.BackupDevice{} },
+	messages.MessageType_MessageType_EntropyRequest:               func() proto.Message { return &messages.EntropyRequest{} },
+	messages.MessageType_MessageType_EntropyAck:                   func() proto.Message { return &messages.EntropyAck{} },
+	messages.MessageType_MessageType_PassphraseRequest:            func() proto.Message { return &messages.PassphraseRequest{} },
+	messages.MessageType_MessageType_PassphraseAck:                func() proto.Message { return &messages.PassphraseAck{} },
+	messages.MessageType_MessageType_RecoveryDevice:               func() proto.Message { return &messages.RecoveryDevice{} },
+	messages.MessageType_MessageType_WordRequest:                  func() proto.Message { return &messages.WordRequest{} },
+	messages.MessageType_MessageType_WordAck:                      func() proto.Message { return &messages.WordAck{} },
+	messages.MessageType_MessageType_GetFeatures:                  func() proto.Message { return &messages.GetFeatures{} },
+	messages.MessageType_MessageType_PassphraseStateRequest:       func() proto.Message { return &messages.PassphraseStateRequest{} },
+	messages.MessageType_MessageType_PassphraseStateAck:           func() proto.Message { return &messages.PassphraseStateAck{} },
+	messages.MessageType_MessageType_SetMnemonic:                  func() proto.Message { return &messages.SetMnemonic{} },
+	messages.MessageType_MessageType_SkycoinAddress:               func() proto.Message { return &messages.SkycoinAddress{} },
+	messages.MessageType_MessageType_SkycoinCheckMessageSignature: func() proto.Message { return &messages.SkycoinCheckMessageSignature{} },
+	messages.MessageType_MessageType_SkycoinSignMessage:           func() proto.Message { return &messages.SkycoinSignMessage{} },
+	messages.MessageType_MessageType_ResponseSkycoinAddress:       func() proto.Message { return &messages.ResponseSkycoinAddress{} },
+	messages.MessageType_MessageType_ResponseSkycoinSignMessage:   func() proto.Message { return &messages.ResponseSkycoinSignMessage{} },
+	messages.MessageType_MessageType_GenerateMnemonic:             func() proto.Message { return &messages.GenerateMnemonic{} },
+	messages.MessageType_MessageType_TransactionSign:              func() proto.Message { return &messages.TransactionSign{} },
+	messages.MessageType_MessageType_ResponseTransactionSign:      func() proto.Message { return &messages.ResponseTransactionSign{} },
+	messages.MessageType_MessageType_GetMixedEntropy:              func() proto.Message { return &messages.GetMixedEntropy{} },
+	messages.MessageType_MessageType_SignTx:                       func() proto.Message { return &messages.SignTx{} },
+	messages.MessageType_MessageType_TxRequest:                    func() proto.Message { return &messages.TxRequest{} },
+	messages.MessageType_MessageType_TxAck:                        func() proto.Message { return &messages.TxAck{} },
+	messages.MessageType_MessageType_BitcoinTxAck:                 func() proto.Message { return &messages.BitcoinTxAck{} },
+	messages.MessageType_MessageType_BitcoinAddress:               func() proto.Message { return &messages.BitcoinAddress{} },
+	messages.MessageType_MessageType_EthereumTxAck:                func() proto.Message { return &messages.EthereumTxAck{} },
+	messages.MessageType_MessageType_EthereumAddress:              func() proto.Message { return &messages.EthereumAddress{} },
+	messages.MessageType_MessageType_ResponseEthereumAddress:      func() proto.Message { return &messages.ResponseEthereumAddress{} },
+}
+
+// messageTypes maps the protobuf message types back to their MessageType
+var messageTypes = make(map[reflect.Type]messages.MessageType, len(messageConstructors))
+
+func init() {
+	for kind, constructor := range messageConstructors {
+		messageTypes[reflect.TypeOf(constructor())] = kind
+	}
+}
+
+// UnknownMessageTypeError is returned for messages whose type is not in the registry
+type UnknownMessageTypeError struct {
+	Kind uint16
+}
+
+func (e UnknownMessageTypeError) Error() string {
+	return fmt.Sprintf("unknown message type: %d", e.Kind)
+}
+
+// NewMessage returns an empty protobuf message of type kind
+func NewMessage(kind messages.MessageType) (proto.Message, error) {
+	constructor, ok := messageConstructors[kind]
+	if !ok {
+		return nil, UnknownMessageTypeError{Kind: uint16(kind)}
+	}
+	return constructor(), nil
+}
+
+// MessageTypeOf returns the MessageType of a protobuf message
+func MessageTypeOf(m proto.Message) (messages.MessageType, error) {
+	kind, ok := messageTypes[reflect.TypeOf(m)]
+	if !ok {
+		return 0, fmt.Errorf("%T is not a device message", m)
+	}
+	return kind, nil
+}
+
+// Decode decodes msg into the protobuf message of its type
+func Decode(msg wire.Message) (proto.Message, error) {
+	m, err := NewMessage(messages.MessageType(msg.Kind))
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(msg.Data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Encode encodes a protobuf message as a message of its type
+func Encode(m proto.Message) (wire.Message, error) {
+	kind, err := MessageTypeOf(m)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return wire.Message{Kind: uint16(kind), Data: data}, nil
+}
+
+// decodeAs decodes msg into m, msg must be of the type of m
+func decodeAs(msg wire.Message, m proto.Message) error {
+	kind, err := MessageTypeOf(m)
+	if err != nil {
+		return err
+	}
+	if msg.Kind != uint16(kind) {
+		return unexpectedMessage(msg, kind)
+	}
+	if err := proto.Unmarshal(msg.Data, m); err != nil {
+		return ProtocolError{Err: err}
+	}
+	return nil
+}
+
+// encodePackets encodes m and frames it in the packets sent to the device
+func encodePackets(m proto.Message) ([][64]byte, error) {
+	msg, err := Encode(m)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Packets()
+}
@@ -0,0 +1,65 @@
+package skywallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestMessageRegistry(t *testing.T) {
+	require.Len(t, messageConstructors, len(messages.MessageType_name))
+	for value, name := range messages.MessageType_name {
+		kind := messages.MessageType(value)
+		m, err := NewMessage(kind)
+		require.NoError(t, err, name)
+		require.Equal(t, strings.TrimPrefix(name, "MessageType_"), proto.MessageName(m))
+
+		k, err := MessageTypeOf(m)
+		require.NoError(t, err)
+		require.Equal(t, kind, k)
+	}
+
+	_, err := NewMessage(messages.MessageType(1000))
+	require.Equal(t, UnknownMessageTypeError{Kind: 1000}, err)
+	_, err = MessageTypeOf(&messages.TxAck_TransactionType{})
+	require.EqualError(t, err, "*messages.TxAck_TransactionType is not a device message")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	features := &messages.Features{
+		Vendor:       proto.String("Skycoin Foundation"),
+		MajorVersion: proto.Uint32(1),
+		DeviceId:     proto.String("ABCD"),
+	}
+
+	msg, err := Encode(features)
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Features), msg.Kind)
+
+	decoded, err := Decode(msg)
+	require.NoError(t, err)
+	require.Equal(t, features, decoded)
+
+	_, err = Decode(wire.Message{Kind: 1000})
+	require.Equal(t, UnknownMessageTypeError{Kind: 1000}, err)
+
+	_, err = Decode(wire.Message{Kind: msg.Kind, Data: []byte{0x0a, 0x05}})
+	require.Error(t, err)
+}
+
+func TestDecodeAs(t *testing.T) {
+	msg, err := Encode(&messages.Success{Message: proto.String("ok")})
+	require.NoError(t, err)
+
+	success, err := DecodeSuccessMsg(msg)
+	require.NoError(t, err)
+	require.Equal(t, "ok", success)
+
+	_, err = DecodeFailMsg(msg)
+	require.EqualError(t, err, "protocol error: expected Failure, received Success")
+}
@@ -0,0 +1,62 @@
+package skywallet
+
+import (
+	"errors"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+var (
+	// ErrInvalidStrength is returned if the seed strength is not 128, 192 or 256 bits
+	ErrInvalidStrength = errors.New("strength must be 128, 192 or 256 bits")
+)
+
+// ResetDeviceOptions configures the seed generated by ResetDevice and the device settings
+type ResetDeviceOptions struct {
+	// DisplayRandom shows the internal entropy on the device screen before it is mixed with the host entropy
+	DisplayRandom bool
+	// Strength of the seed in bits: 128 for 12 words, 192 for 18 words or 256 for 24 words. 256 if zero.
+	Strength             uint32
+	PassphraseProtection bool
+	// PinProtection makes the device ask for a new PIN code
+	PinProtection bool
+	Language      string
+	Label         string
+	U2FCounter    uint32
+	// SkipBackup leaves the seed backup for later, with the Backup procedure
+	SkipBackup bool
+}
+
+// Validate checks the seed strength
+func (opts ResetDeviceOptions) Validate() error {
+	switch opts.Strength {
+	case 0, 128, 192, 256:
+		return nil
+	default:
+		return ErrInvalidStrength
+	}
+}
+
+// ResetDevice asks the device to generate a new seed from its internal entropy mixed
+// with host entropy and to configure itself as described by opts.
+// The device asks for the host entropy with an EntropyRequest, answered with random
+// bytes whose SHA-256 hash is logged so the contribution can be audited.
+// The answer is usually a ButtonRequest, followed by PIN requests if PinProtection
+// is set and by the backup procedure unless SkipBackup is set.
+func (d *Device) ResetDevice(opts ResetDeviceOptions) (wire.Message, error) {
+	if err := opts.Validate(); err != nil {
+		return wire.Message{}, err
+	}
+
+	if err := d.Connect(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	resetDeviceChunks, err := MessageResetDevice(opts)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.Driver.SendToDevice(d.dev, resetDeviceChunks)
+}
@@ -0,0 +1,58 @@
+package skywallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestResetDevice(t *testing.T) {
+	buttonRequest, err := Encode(&messages.ButtonRequest{})
+	require.NoError(t, err)
+
+	resetDevice := &messages.ResetDevice{}
+	// readResetDevice decodes the ResetDevice sent and asks for a button press
+	readResetDevice := func(_ usb.Device, chunks [][64]byte) wire.Message {
+		var buf bytes.Buffer
+		for _, c := range chunks {
+			buf.Write(c[:])
+		}
+		msg, err := wire.ReadFrom(&buf)
+		require.NoError(t, err)
+		require.NoError(t, decodeAs(*msg, resetDevice))
+		return buttonRequest
+	}
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(readResetDevice, nil).Once()
+	device := getMockDevice(driverMock)
+
+	msg, err := device.ResetDevice(ResetDeviceOptions{
+		DisplayRandom: true,
+		Strength:      128,
+		PinProtection: true,
+		Label:         "treasury",
+		SkipBackup:    true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, buttonRequest, msg)
+	require.Equal(t, &messages.ResetDevice{
+		DisplayRandom:        proto.Bool(true),
+		Strength:             proto.Uint32(128),
+		PassphraseProtection: proto.Bool(false),
+		PinProtection:        proto.Bool(true),
+		Label:                proto.String("treasury"),
+		SkipBackup:           proto.Bool(true),
+	}, resetDevice)
+
+	_, err = device.ResetDevice(ResetDeviceOptions{Strength: 160})
+	require.Equal(t, ErrInvalidStrength, err)
+}
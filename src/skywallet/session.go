@@ -0,0 +1,181 @@
+package skywallet
+
+import (
+	"errors"
+	"sync"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+var (
+	// ErrSessionClosed is returned by the methods of a closed Session
+	ErrSessionClosed = errors.New("session is closed")
+)
+
+// Session keeps the connection to the device open across calls, so that the device
+// is initialized once and multi-step flows do not claim and release the usb interface
+// on every message. The device methods are called as usual while the session is open,
+// an explicit Device.Disconnect still closes the connection and Resume opens it again.
+type Session struct {
+	device *Device
+
+	mu       sync.Mutex
+	closed   bool
+	features *messages.Features
+}
+
+// OpenSession connects to the device, keeping the connection until the session is
+// closed, and initializes it, resuming the session of the passphrase state
+// remembered for the device if any
+func (d *Device) OpenSession() (*Session, error) {
+	if err := d.Connect(); err != nil {
+		return nil, err
+	}
+	d.Lock()
+	d.sessions++
+	d.Unlock()
+
+	s := &Session{device: d}
+	if err := s.initialize(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Initialize sends Initialize to the device with the passphrase state remembered for it,
+// so the device keeps the cached PIN and passphrase only if they belong to that state.
+// The answer is usually the device Features.
+func (d *Device) Initialize() (wire.Message, error) {
+	if err := d.Connect(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	initializeChunks, err := MessageInitializeState(d.passphraseStates.current())
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	msg, err := d.Driver.SendToDevice(d.dev, initializeChunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	if msg.Kind == uint16(messages.MessageType_MessageType_Features) {
+		if features, err := DecodeFeaturesMsg(msg); err == nil {
+			d.passphraseStates.setDeviceID(features.GetDeviceId())
+		}
+	}
+	return msg, nil
+}
+
+// initialize initializes the device and records its features
+func (s *Session) initialize() error {
+	msg, err := s.device.Initialize()
+	if err != nil {
+		return err
+	}
+	return s.setFeatures(msg)
+}
+
+// setFeatures records the features answered by the device
+func (s *Session) setFeatures(msg wire.Message) error {
+	features, err := DecodeFeaturesMsg(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.features = features
+	return nil
+}
+
+// Features returns the features of the device as of the last Initialize or Refresh
+func (s *Session) Features() *messages.Features {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.features
+}
+
+// PinCached tells whether the device had the PIN code cached as of the last Initialize or Refresh
+func (s *Session) PinCached() bool {
+	return s.Features().GetPinCached()
+}
+
+// PassphraseCached tells whether the device had the passphrase cached as of the last Initialize or Refresh
+func (s *Session) PassphraseCached() bool {
+	return s.Features().GetPassphraseCached()
+}
+
+// Refresh asks the device its features again, without clearing its cached PIN and passphrase
+func (s *Session) Refresh() error {
+	if s.isClosed() {
+		return ErrSessionClosed
+	}
+	msg, err := s.device.GetFeatures()
+	if err != nil {
+		return err
+	}
+	return s.setFeatures(msg)
+}
+
+// Resume checks the device still answers and re-initializes the session only if needed:
+// if the connection was lost, it is opened again, and if another device answers, the
+// session is initialized for it
+func (s *Session) Resume() error {
+	if s.isClosed() {
+		return ErrSessionClosed
+	}
+
+	deviceID := s.Features().GetDeviceId()
+	if err := s.Refresh(); err != nil {
+		log.Warnf("device did not answer, reconnecting: %v", err)
+		s.device.reconnect()
+		if err := s.device.Connect(); err != nil {
+			return err
+		}
+		return s.initialize()
+	}
+	if s.Features().GetDeviceId() != deviceID {
+		return s.initialize()
+	}
+	return nil
+}
+
+// Close releases the connection to the device
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.device.Lock()
+	s.device.sessions--
+	s.device.Unlock()
+	return s.device.release()
+}
+
+func (s *Session) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// reconnect drops the connection even while sessions are open, so the next call connects again
+func (d *Device) reconnect() {
+	d.Lock()
+	defer d.Unlock()
+	if d.connected {
+		if err := d.dev.Close(true); err != nil {
+			log.Warnf("failed to close the lost connection: %v", err)
+		}
+		d.dev = nil
+		d.connected = false
+	}
+}
@@ -0,0 +1,145 @@
+package skywallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestSession(t *testing.T) {
+	encode := func(m proto.Message) wire.Message {
+		msg, err := Encode(m)
+		require.NoError(t, err)
+		return msg
+	}
+	// expect checks the message sent is m and answers it with answer
+	expect := func(m proto.Message, answer wire.Message) func(usb.Device, [][64]byte) wire.Message {
+		return func(_ usb.Device, chunks [][64]byte) wire.Message {
+			var buf bytes.Buffer
+			for _, c := range chunks {
+				buf.Write(c[:])
+			}
+			msg, err := wire.ReadFrom(&buf)
+			require.NoError(t, err)
+			sent, err := Decode(*msg)
+			require.NoError(t, err)
+			require.Equal(t, m, sent)
+			return answer
+		}
+	}
+
+	cached := encode(&messages.Features{
+		DeviceId:         proto.String("ABCD"),
+		PinCached:        proto.Bool(true),
+		PassphraseCached: proto.Bool(true),
+	})
+	success := encode(&messages.Success{})
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	device := getMockDevice(driverMock)
+
+	// Initialize is sent once and the connection kept across calls
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.Initialize{}, cached), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(success, nil).Twice()
+	session, err := device.OpenSession()
+	require.NoError(t, err)
+	require.True(t, session.PinCached())
+	require.True(t, session.PassphraseCached())
+	_, err = device.Wipe()
+	require.NoError(t, err)
+	_, err = device.Wipe()
+	require.NoError(t, err)
+	require.True(t, device.connected)
+	driverMock.AssertNumberOfCalls(t, "GetDevice", 1)
+
+	// the session resumes without Initialize while the same device answers
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.GetFeatures{}, cached), nil).Once()
+	require.NoError(t, session.Resume())
+	driverMock.AssertNumberOfCalls(t, "SendToDevice", 4)
+
+	// a lost connection is opened again and initialized with the remembered passphrase state
+	device.passphraseStates.check([]byte("state"))
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(wire.Message{}, errors.New("disconnected")).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(expect(&messages.Initialize{State: []byte("state")}, encode(&messages.Features{
+		DeviceId: proto.String("ABCD"),
+	})), nil).Once()
+	require.NoError(t, session.Resume())
+	require.False(t, session.PinCached())
+	driverMock.AssertNumberOfCalls(t, "GetDevice", 2)
+
+	// the connection is released once the session is closed
+	require.NoError(t, session.Close())
+	require.False(t, device.connected)
+	require.Equal(t, ErrSessionClosed, session.Refresh())
+	require.NoError(t, session.Close())
+}
+
+// blockingDevice blocks the reads until it is closed
+type blockingDevice struct {
+	testHelperCloseableBuffer
+	reading chan struct{}
+	closed  chan struct{}
+}
+
+func (b *blockingDevice) Close(disconnect bool) error {
+	close(b.closed)
+	return nil
+}
+
+func TestSessionPingTimeout(t *testing.T) {
+	features, err := Encode(&messages.Features{DeviceId: proto.String("ABCD")})
+	require.NoError(t, err)
+
+	dev := &blockingDevice{reading: make(chan struct{}), closed: make(chan struct{})}
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(dev, nil).Once()
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(features, nil).Once()
+	// the ping is never answered, the read only ends when the device is closed
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		func(d usb.Device, _ [][64]byte) wire.Message {
+			close(d.(*blockingDevice).reading)
+			<-d.(*blockingDevice).closed
+			return wire.Message{}
+		},
+		func(usb.Device, [][64]byte) error {
+			return errors.New("device closed")
+		}).Once()
+	device := getMockDevice(driverMock)
+
+	session, err := device.OpenSession()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = device.Ping(ctx, "", PingOptions{})
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	// Disconnect unblocks the pending read even though the session is open
+	<-dev.reading
+	require.NoError(t, device.Disconnect())
+	select {
+	case <-dev.closed:
+	case <-time.After(time.Second):
+		t.Fatal("the device was not closed")
+	}
+	require.False(t, device.connected)
+
+	// the session connects again on resume
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(features, nil).Once()
+	require.NoError(t, session.Resume())
+	require.True(t, device.connected)
+	require.NoError(t, session.Close())
+	require.False(t, device.connected)
+}
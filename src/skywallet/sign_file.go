@@ -0,0 +1,133 @@
+package skywallet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+var (
+	// ErrDigestMismatch is returned if a file does not match the digest of its signature
+	ErrDigestMismatch = errors.New("file digest does not match the signed digest")
+)
+
+// SignerMismatchError is returned if a file signature was issued by another address than the expected one
+type SignerMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e SignerMismatchError) Error() string {
+	return fmt.Sprintf("file signed by %s, expected %s", e.Actual, e.Expected)
+}
+
+// FileSignature is a detached signature of a file made by the device
+type FileSignature struct {
+	// Address that issued the signature
+	Address string `json:"address"`
+	// AddressIndex is the index of the address in the device wallet
+	AddressIndex int `json:"address_index"`
+	// Digest is the hex encoded SHA-256 of the file
+	Digest string `json:"digest"`
+	// Signature of the digest as returned by SignMessage
+	Signature string `json:"signature"`
+	// FirmwareVersion of the device that signed the file, if it reports it
+	FirmwareVersion string `json:"firmware_version,omitempty"`
+}
+
+// FileDigest returns the SHA-256 digest of the file at path
+func FileDigest(path string) (cipher.SHA256, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cipher.SHA256{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return cipher.SHA256{}, err
+	}
+	return cipher.SHA256FromBytes(h.Sum(nil))
+}
+
+// SignFile asks the device to sign the SHA-256 digest of the file at path with
+// the secret key at addressIndex. readPin and readPassphrase are called when the
+// device asks for them. The signature is checked before it is returned.
+func (d *Device) SignFile(path string, addressIndex int, readPin, readPassphrase func() (string, error)) (*FileSignature, error) {
+	digest, err := FileDigest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := d.DeviceInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := d.AddressGen(1, uint32(addressIndex), false, SkycoinCoinType)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_ResponseSkycoinAddress, readPin, readPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := DecodeResponseSkycoinAddress(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != 1 {
+		return nil, errors.New("device did not return the signing address")
+	}
+
+	msg, err = d.SignMessage(addressIndex, digest.Hex(), SkycoinCoinType)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_ResponseSkycoinSignMessage, readPin, readPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := DecodeResponseSkycoinSignMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSignature := &FileSignature{
+		Address:      addresses[0],
+		AddressIndex: addressIndex,
+		Digest:       digest.Hex(),
+		Signature:    signature,
+	}
+	if info.FirmwareVersion != nil {
+		fileSignature.FirmwareVersion = info.FirmwareVersion.String()
+	}
+
+	if err := VerifyMessageSignature(fileSignature.Digest, fileSignature.Signature, fileSignature.Address); err != nil {
+		return nil, err
+	}
+	return fileSignature, nil
+}
+
+// VerifyFileSignature checks offline that the file at path matches the digest
+// of the signature and that the digest was signed by address. The address in the
+// signature is not trusted, a SignerMismatchError is returned if it is another one.
+func VerifyFileSignature(path string, signature FileSignature, address string) error {
+	if signature.Address != address {
+		return SignerMismatchError{Expected: address, Actual: signature.Address}
+	}
+
+	digest, err := FileDigest(path)
+	if err != nil {
+		return err
+	}
+	if digest.Hex() != signature.Digest {
+		return ErrDigestMismatch
+	}
+	return VerifyMessageSignature(signature.Digest, signature.Signature, signature.Address)
+}
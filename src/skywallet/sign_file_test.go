@@ -0,0 +1,79 @@
+package skywallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestSignFile(t *testing.T) {
+	// NOTE: Giving
+	dir, err := ioutil.TempDir("", "signfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "release.tar.gz")
+	content := []byte("release artifact")
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	pubKey, secKey := cipher.MustGenerateDeterministicKeyPair([]byte("signfile"))
+	address := cipher.AddressFromPubKey(pubKey).String()
+	digest := cipher.SumSHA256(content)
+	sig := cipher.MustSignHash(digest, secKey)
+
+	features, err := proto.Marshal(&messages.Features{
+		MajorVersion: proto.Uint32(1),
+		MinorVersion: proto.Uint32(8),
+		PatchVersion: proto.Uint32(0),
+	})
+	require.NoError(t, err)
+	addresses, err := proto.Marshal(&messages.ResponseSkycoinAddress{Addresses: []string{address}})
+	require.NoError(t, err)
+	signed, err := proto.Marshal(&messages.ResponseSkycoinSignMessage{SignedMessage: proto.String(sig.Hex())})
+	require.NoError(t, err)
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: features}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: addresses}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage), Data: signed}, nil).Once()
+	device := getMockDevice(driverMock)
+
+	// NOTE: When
+	signature, err := device.SignFile(path, 3, nil, nil)
+
+	// NOTE: Assert
+	require.NoError(t, err)
+	require.Equal(t, &FileSignature{
+		Address:         address,
+		AddressIndex:    3,
+		Digest:          digest.Hex(),
+		Signature:       sig.Hex(),
+		FirmwareVersion: "1.8.0",
+	}, signature)
+	require.NoError(t, VerifyFileSignature(path, *signature, address))
+
+	// a valid bundle of another key is rejected
+	otherPubKey, otherSecKey := cipher.MustGenerateDeterministicKeyPair([]byte("attacker"))
+	other := FileSignature{
+		Address:   cipher.AddressFromPubKey(otherPubKey).String(),
+		Digest:    digest.Hex(),
+		Signature: cipher.MustSignHash(digest, otherSecKey).Hex(),
+	}
+	require.NoError(t, VerifyFileSignature(path, other, other.Address))
+	require.Equal(t, SignerMismatchError{Expected: address, Actual: other.Address}, VerifyFileSignature(path, other, address))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("tampered artifact"), 0600))
+	require.Equal(t, ErrDigestMismatch, VerifyFileSignature(path, *signature, address))
+}
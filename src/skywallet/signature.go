@@ -0,0 +1,134 @@
+package skywallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/base58"
+)
+
+// bitcoinMessageMagic prefixes messages signed in the Bitcoin Signed Message format
+const bitcoinMessageMagic = "Bitcoin Signed Message:\n"
+
+var (
+	// ErrInvalidBitcoinSignature is returned if a signature is not a base64 encoded Bitcoin Signed Message signature
+	ErrInvalidBitcoinSignature = errors.New("signature must be 65 base64 encoded bytes with a header byte between 27 and 34")
+	// ErrUncompressedPubKey is returned if a bitcoin signature was made for an uncompressed public key
+	ErrUncompressedPubKey = errors.New("signatures of uncompressed public keys are not supported")
+)
+
+// MessageHash returns the digest the firmware signs for message.
+// Like the firmware, a message made of 64 hex characters is taken as an
+// already computed SHA-256 digest, any other message is hashed with SHA-256.
+func MessageHash(message string) cipher.SHA256 {
+	if len(message) == 2*len(cipher.SHA256{}) {
+		if digest, err := cipher.SHA256FromHex(message); err == nil {
+			return digest
+		}
+	}
+	return cipher.SumSHA256([]byte(message))
+}
+
+// VerifyMessageSignature checks offline that signature was produced over message
+// by the secret key of address, as the device does for CheckMessageSignature.
+// The signature is hex encoded as returned by SignMessage, the base58 encoding of
+// older firmware versions is accepted too.
+// Returns nil if the signature is valid.
+func VerifyMessageSignature(message, signature, address string) error {
+	addr, err := cipher.DecodeBase58Address(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %s: %v", address, err)
+	}
+
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	return cipher.VerifyAddressSignedHash(addr, sig, MessageHash(message))
+}
+
+// decodeSignature decodes a hex or base58 encoded signature
+func decodeSignature(signature string) (cipher.Sig, error) {
+	b, err := hex.DecodeString(signature)
+	if err != nil {
+		b, err = base58.Decode(signature)
+		if err != nil {
+			return cipher.Sig{}, fmt.Errorf("signature is neither hex nor base58 encoded")
+		}
+	}
+	return cipher.NewSig(b)
+}
+
+// BitcoinMessageHash returns the double SHA-256 digest of message in the Bitcoin Signed Message format
+func BitcoinMessageHash(message string) cipher.SHA256 {
+	var b bytes.Buffer
+	writeVarString(&b, bitcoinMessageMagic)
+	writeVarString(&b, message)
+	return cipher.DoubleSHA256(b.Bytes())
+}
+
+// EncodeBitcoinMessageSignature encodes a signature of the device as a base64
+// Bitcoin Signed Message signature. The header byte tells the recovery id and that
+// the key is compressed, as the device keys always are.
+func EncodeBitcoinMessageSignature(sig cipher.Sig) string {
+	b := make([]byte, len(sig))
+	b[0] = 27 + 4 + sig[64]
+	copy(b[1:], sig[:64])
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// VerifyBitcoinMessageSignature checks offline that signature is a Bitcoin Signed Message
+// signature of message made by the secret key of the bitcoin address.
+// Returns nil if the signature is valid.
+func VerifyBitcoinMessageSignature(message, signature, address string) error {
+	addr, err := cipher.DecodeBase58BitcoinAddress(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %s: %v", address, err)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(b) != 65 || b[0] < 27 || b[0] > 34 {
+		return ErrInvalidBitcoinSignature
+	}
+	if b[0] < 31 {
+		return ErrUncompressedPubKey
+	}
+	var sig cipher.Sig
+	copy(sig[:64], b[1:])
+	sig[64] = (b[0] - 27) & 3
+
+	hash := BitcoinMessageHash(message)
+	pubKey, err := cipher.PubKeyFromSig(sig, hash)
+	if err != nil {
+		return err
+	}
+	if cipher.BitcoinAddressFromPubKey(pubKey) != addr {
+		return cipher.ErrInvalidAddressForSig
+	}
+	return cipher.VerifyPubKeySignedHash(pubKey, sig, hash)
+}
+
+// writeVarString writes s prefixed by its length encoded as a bitcoin variable length integer
+func writeVarString(b *bytes.Buffer, s string) {
+	n := uint64(len(s))
+	switch {
+	case n < 0xfd:
+		b.WriteByte(byte(n))
+	case n <= 0xffff:
+		b.WriteByte(0xfd)
+		binary.Write(b, binary.LittleEndian, uint16(n))
+	case n <= 0xffffffff:
+		b.WriteByte(0xfe)
+		binary.Write(b, binary.LittleEndian, uint32(n))
+	default:
+		b.WriteByte(0xff)
+		binary.Write(b, binary.LittleEndian, n)
+	}
+	b.WriteString(s)
+}
@@ -0,0 +1,174 @@
+package skywallet
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestVerifyMessageSignature(t *testing.T) {
+	const (
+		address   = "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"
+		signature = "f026001ee2d4e6bf4dfdbbdd33b6622bae24c8f6232fc19e5dd0013f2b68f0f14606d448897df20348c8b63dcad6923e9fecb2fe0969b183dc31eb4796e001d101"
+	)
+	sig, err := hex.DecodeString(signature)
+	require.NoError(t, err)
+	otherPubKey, _ := cipher.MustGenerateDeterministicKeyPair([]byte("other"))
+
+	tt := []struct {
+		name      string
+		message   string
+		signature string
+		address   string
+		err       error
+	}{
+		{
+			name:      "valid hex signature",
+			message:   "Hello World",
+			signature: signature,
+			address:   address,
+		},
+		{
+			name:      "valid base58 signature",
+			message:   "Hello World",
+			signature: base58.Encode(sig),
+			address:   address,
+		},
+		{
+			name:      "message digest",
+			message:   cipher.SumSHA256([]byte("Hello World")).Hex(),
+			signature: signature,
+			address:   address,
+		},
+		{
+			name:      "other message",
+			message:   "Hello World!",
+			signature: signature,
+			address:   address,
+			err:       cipher.ErrInvalidAddressForSig,
+		},
+		{
+			name:      "other address",
+			message:   "Hello World",
+			signature: signature,
+			address:   cipher.AddressFromPubKey(otherPubKey).String(),
+			err:       cipher.ErrInvalidAddressForSig,
+		},
+		{
+			name:      "short signature",
+			message:   "Hello World",
+			signature: signature[:64],
+			address:   address,
+			err:       cipher.ErrInvalidLengthSig,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyMessageSignature(tc.message, tc.signature, tc.address)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestVerifyMessageSignatureInvalidInput(t *testing.T) {
+	require.Error(t, VerifyMessageSignature("Hello World", "not a signature!", "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"))
+	require.Error(t, VerifyMessageSignature("Hello World", "00", "not an address"))
+}
+
+func TestBitcoinMessageHash(t *testing.T) {
+	require.Equal(t, "a7af0baad5ae99b97fc69b3a0d1abcf3ef17f131cc4776e1bc11933ec8550f49", BitcoinMessageHash("Hello World").Hex())
+	require.Equal(t, "3ec158a43b80359df647352dac1d37dbf26a94e5f06e5790760290c75cd11dc0", BitcoinMessageHash(strings.Repeat("a", 300)).Hex())
+}
+
+func TestVerifyBitcoinMessageSignature(t *testing.T) {
+	pubKey, secKey := cipher.MustGenerateDeterministicKeyPair([]byte("bitcoin"))
+	address := cipher.BitcoinAddressFromPubKey(pubKey).String()
+	otherPubKey, _ := cipher.MustGenerateDeterministicKeyPair([]byte("other"))
+	sig := cipher.MustSignHash(BitcoinMessageHash("Hello World"), secKey)
+	signature := EncodeBitcoinMessageSignature(sig)
+
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Equal(t, 31+sig[64], raw[0])
+	raw[0] -= 4
+	uncompressed := base64.StdEncoding.EncodeToString(raw)
+
+	tt := []struct {
+		name      string
+		message   string
+		signature string
+		address   string
+		err       error
+	}{
+		{
+			name:      "valid signature",
+			message:   "Hello World",
+			signature: signature,
+			address:   address,
+		},
+		{
+			name:      "other message",
+			message:   "Hello World!",
+			signature: signature,
+			address:   address,
+			err:       cipher.ErrInvalidAddressForSig,
+		},
+		{
+			name:      "other address",
+			message:   "Hello World",
+			signature: signature,
+			address:   cipher.BitcoinAddressFromPubKey(otherPubKey).String(),
+			err:       cipher.ErrInvalidAddressForSig,
+		},
+		{
+			name:      "hex signature",
+			message:   "Hello World",
+			signature: sig.Hex(),
+			address:   address,
+			err:       ErrInvalidBitcoinSignature,
+		},
+		{
+			name:      "uncompressed public key",
+			message:   "Hello World",
+			signature: uncompressed,
+			address:   address,
+			err:       ErrUncompressedPubKey,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyBitcoinMessageSignature(tc.message, tc.signature, tc.address)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestDecodeResponseSignMessage(t *testing.T) {
+	_, secKey := cipher.MustGenerateDeterministicKeyPair([]byte("bitcoin"))
+	sig := cipher.MustSignHash(BitcoinMessageHash("Hello World"), secKey)
+	data, err := proto.Marshal(&messages.ResponseSkycoinSignMessage{SignedMessage: proto.String(sig.Hex())})
+	require.NoError(t, err)
+	msg := wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage), Data: data}
+
+	signature, err := DecodeResponseSignMessage(msg, SkycoinCoinType)
+	require.NoError(t, err)
+	require.Equal(t, sig.Hex(), signature)
+
+	signature, err = DecodeResponseSignMessage(msg, BitcoinCoinType)
+	require.NoError(t, err)
+	require.Equal(t, EncodeBitcoinMessageSignature(sig), signature)
+
+	_, err = DecodeResponseSignMessage(msg, InvalidCoinType)
+	require.EqualError(t, err, "invalid coin type: invalid")
+}
@@ -7,8 +7,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
-
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
 	"github.com/skycoin/hardware-wallet-go/src/skywallet/usb"
 
 	"github.com/skycoin/skycoin/src/util/logging"
@@ -71,7 +70,7 @@ type Devicer interface {
 	SetMnemonic(mnemonic string) (wire.Message, error)
 	TransactionSign(inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (wire.Message, error)
 	GeneralTransactionSign(signer TransactionSigner) ([]string, error)
-	SignMessage(addressIndex int, message string) (wire.Message, error)
+	SignMessage(addressIndex int, message string, coinType CoinType) (wire.Message, error)
 	Wipe() (wire.Message, error)
 	PinMatrixAck(p string) (wire.Message, error)
 	WordAck(word string) (wire.Message, error)
@@ -93,6 +92,10 @@ type Device struct {
 	connected           bool
 	simulateButtonPress bool
 	simulateButtonType  ButtonType
+	entropy             entropySourceHolder
+	passphraseStates    passphraseStates
+	// sessions is the number of open sessions keeping the connection
+	sessions int
 }
 
 // DeviceTypeFromString returns device type from string
@@ -121,6 +124,13 @@ func newDevice(deviceType DeviceType) *Device {
 		log.Fatalf("failed to create driver: %s", err)
 	}
 
+	return NewDeviceWithDriver(driver)
+}
+
+// NewDeviceWithDriver returns a new device communicating through driver.
+// Unlike NewDevice it is not a singleton, so several devices can be used at once,
+// i.e. emulators listening on different ports.
+func NewDeviceWithDriver(driver DeviceDriver) *Device {
 	return &Device{
 		driver,
 		sync.Mutex{},
@@ -128,9 +138,26 @@ func newDevice(deviceType DeviceType) *Device {
 		false,
 		false,
 		ButtonType(-1),
+		entropySourceHolder{},
+		passphraseStates{},
+		0,
+	}
+}
+
+// SetEntropySource sets the source of the host entropy sent when the device asks for it.
+// DefaultEntropySource is used if source is nil.
+func (d *Device) SetEntropySource(source EntropySource) {
+	d.entropy.SetEntropySource(source)
+	if driver, ok := d.Driver.(entropySourceSetter); ok {
+		driver.SetEntropySource(source)
 	}
 }
 
+// EntropySource returns the source of the host entropy sent when the device asks for it
+func (d *Device) EntropySource() EntropySource {
+	return d.entropy.EntropySource()
+}
+
 // NewDevice returns a new device instance
 func NewDevice(deviceType DeviceType) *Device {
 	// TODO rename NewDevice to DeviceInstance as this is a singleton
@@ -162,10 +189,25 @@ func (d *Device) Connect() error {
 	return nil
 }
 
-// Disconnect the device
+// Disconnect the device.
+// The connection is closed even while a Session is open, the next call connects again.
 func (d *Device) Disconnect() error {
 	d.Lock()
 	defer d.Unlock()
+	return d.disconnect()
+}
+
+// release disconnects the device at the end of a call, unless a Session keeps the connection
+func (d *Device) release() error {
+	d.Lock()
+	defer d.Unlock()
+	if d.sessions > 0 {
+		return nil
+	}
+	return d.disconnect()
+}
+
+func (d *Device) disconnect() error {
 	if d.connected {
 		err := d.dev.Close(false)
 		if err == nil {
@@ -183,7 +225,7 @@ func (d *Device) GetUsbInfo() ([]usb.Info, error) {
 		if err := d.Connect(); err != nil {
 			return nil, err
 		}
-		if err := d.Disconnect(); err != nil {
+		if err := d.release(); err != nil {
 			return nil, err
 		}
 	}
@@ -194,12 +236,13 @@ func (d *Device) GetUsbInfo() ([]usb.Info, error) {
 	return devInfos, nil
 }
 
-// AddressGen Ask the device to generate an address
+// AddressGen Ask the device to generate an address.
+// An UnsupportedError is returned if the firmware does not know the bitcoin messages.
 func (d *Device) AddressGen(addressN, startIndex uint32, confirmAddress bool, coinType CoinType) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	if addressN == 0 {
 		return wire.Message{}, ErrAddressNZero
@@ -210,7 +253,14 @@ func (d *Device) AddressGen(addressN, startIndex uint32, confirmAddress bool, co
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, addressGenChunks)
+	msg, err := d.Driver.SendToDevice(d.dev, addressGenChunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	if coinType == BitcoinCoinType {
+		return msg, unsupportedFailure(msg, "bitcoin")
+	}
+	return msg, nil
 }
 
 // SaveDeviceEntropyInFile Ask the device to generate entropy and save it in a file
@@ -249,19 +299,13 @@ func (d *Device) SaveDeviceEntropyInFile(outFile string, entropyBytes uint32, ge
 					}
 				}
 
-				msg, err := wire.ReadFrom(d.dev)
+				msg, err := readMessage(d.dev)
 				if err != nil {
 					return nil, err
 				}
 				return processGetEntropyResponse(*msg)
 			}
-			var msgStr string
-			msgStr, err = DecodeFailMsg(msg)
-			if err != nil {
-				log.Errorf("Error decoding device response as fails msg %s", err)
-				return &messages.Entropy{}, err
-			}
-			err = errors.New(msgStr)
+			err = unexpectedMessage(msg, messages.MessageType_MessageType_Entropy)
 			log.Errorf("Error getting entropy from device %s", err)
 			return &messages.Entropy{}, err
 		}
@@ -353,7 +397,7 @@ func (d *Device) SaveDeviceEntropyInFile(outFile string, entropyBytes uint32, ge
 	}
 
 	defer func() {
-		if err := d.Disconnect(); err != nil {
+		if err := d.release(); err != nil {
 			log.Error(err)
 		}
 	}()
@@ -390,7 +434,7 @@ func (d *Device) ApplySettings(usePassphrase *bool, label string, language strin
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	applySettingsChunks, err := MessageApplySettings(usePassphrase, label, language)
 	if err != nil {
@@ -405,7 +449,7 @@ func (d *Device) Backup() (msg wire.Message, err error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 	backupChunks, err := MessageBackup()
 	if err != nil {
 		return wire.Message{}, err
@@ -422,7 +466,7 @@ func (d *Device) Cancel() (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	cancelChunks, err := MessageCancel()
 	if err != nil {
@@ -437,7 +481,7 @@ func (d *Device) CheckMessageSignature(message, signature, address string) (wire
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	// Send CheckMessageSignature
 	checkMessageSignatureChunks, err := MessageCheckMessageSignature(message, signature, address)
@@ -468,7 +512,7 @@ func (d *Device) ChangePin(removePin *bool) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	if removePin == nil {
 		return wire.Message{}, ErrRemovePinNil
@@ -501,44 +545,18 @@ func (d *Device) Connected() bool {
 		return false
 	}
 
-	for _, element := range chunks {
-		_, err = d.dev.Write(element[:])
-		if err != nil {
-			return false
-		}
+	if err = writePackets(d.dev, chunks); err != nil {
+		return false
 	}
 
-	msg, err = wire.ReadFrom(d.dev)
+	msg, err = readMessage(d.dev)
 	if err != nil {
 		return false
 	}
 
-	for msg.Kind == uint16(messages.MessageType_MessageType_EntropyRequest) {
-		var wg sync.WaitGroup
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-			entropyChunks, err := MessageEntropyAck(entropyBufferSize)
-			if err != nil {
-				log.Errorf("failed to create entropy ack msg: %v", err)
-				return
-			}
-
-			for _, element := range entropyChunks {
-				_, err := d.dev.Write(element[:])
-				if err != nil {
-					log.Errorf("entropy ack error: %v", err)
-					return
-				}
-			}
-		}()
-
-		msg, err = wire.ReadFrom(d.dev)
-		if err != nil {
-			return false
-		}
-		wg.Wait()
+	msg, err = answerEntropyRequests(d.dev, msg, d.EntropySource())
+	if err != nil {
+		return false
 	}
 
 	return msg.Kind == uint16(messages.MessageType_MessageType_Success)
@@ -568,7 +586,7 @@ func (d *Device) FirmwareUpload(payload []byte, hash [32]byte) error {
 	if err := d.Connect(); err != nil {
 		return err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	if err := Initialize(d.dev); err != nil {
 		return err
@@ -588,15 +606,8 @@ func (d *Device) FirmwareUpload(payload []byte, hash [32]byte) error {
 	switch erasemsg.Kind {
 	case uint16(messages.MessageType_MessageType_Success):
 		log.Printf("Success %d! FirmwareErase %s\n", erasemsg.Kind, erasemsg.Data)
-	case uint16(messages.MessageType_MessageType_Failure):
-		msg, err := DecodeFailMsg(erasemsg)
-		if err != nil {
-			return err
-		}
-
-		return errors.New(msg)
 	default:
-		return fmt.Errorf("received unexpected message type: %s", messages.MessageType(erasemsg.Kind))
+		return unexpectedMessage(erasemsg, messages.MessageType_MessageType_Success)
 	}
 
 	log.Printf("Hash: %x\n", hash)
@@ -625,24 +636,11 @@ func (d *Device) FirmwareUpload(payload []byte, hash [32]byte) error {
 		switch resp.Kind {
 		case uint16(messages.MessageType_MessageType_Success):
 			return nil
-		case uint16(messages.MessageType_MessageType_Failure):
-			var msgStr string
-			if msgStr, err = DecodeFailMsg(resp); err != nil {
-				return err
-			}
-			return errors.New(msgStr)
 		default:
-			return errors.New("unknown response")
-		}
-	case uint16(messages.MessageType_MessageType_Failure):
-		msg, err := DecodeFailMsg(erasemsg)
-		if err != nil {
-			return err
+			return unexpectedMessage(resp, messages.MessageType_MessageType_Success)
 		}
-
-		return errors.New(msg)
 	default:
-		return fmt.Errorf("received unexpected message type: %s", messages.MessageType(erasemsg.Kind))
+		return unexpectedMessage(uploadmsg, messages.MessageType_MessageType_ButtonRequest)
 	}
 }
 
@@ -651,14 +649,24 @@ func (d *Device) GetFeatures() (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	getFeaturesChunks, err := MessageGetFeatures()
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, getFeaturesChunks)
+	msg, err := d.Driver.SendToDevice(d.dev, getFeaturesChunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	if msg.Kind == uint16(messages.MessageType_MessageType_Features) {
+		// the passphrase states are remembered for the device last asked its features
+		if features, err := DecodeFeaturesMsg(msg); err == nil {
+			d.passphraseStates.setDeviceID(features.GetDeviceId())
+		}
+	}
+	return msg, nil
 }
 
 // GenerateMnemonic Ask the device to generate a mnemonic and configure itself with it.
@@ -666,7 +674,7 @@ func (d *Device) GenerateMnemonic(wordCount uint32, usePassphrase bool) (wire.Me
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	if wordCount != 12 && wordCount != 24 {
 		return wire.Message{}, ErrInvalidWordCount
@@ -690,13 +698,12 @@ func (d *Device) Recovery(wordCount uint32, usePassphrase *bool, dryRun bool) (w
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	if wordCount != 12 && wordCount != 24 {
 		return wire.Message{}, ErrInvalidWordCount
 	}
 
-	log.Printf("Using passphrase %t\n", usePassphrase)
 	recoveryChunks, err := MessageRecovery(wordCount, usePassphrase, dryRun)
 	if err != nil {
 		return wire.Message{}, err
@@ -706,17 +713,70 @@ func (d *Device) Recovery(wordCount uint32, usePassphrase *bool, dryRun bool) (w
 	if err != nil {
 		return wire.Message{}, err
 	}
-	log.Printf("Recovery device response kind is: %d\n", msg.Kind)
 
 	return msg, nil
 }
 
+// VerifyBackup ask the device to perform the seed recovery procedure in dry run mode
+// to check that a written down seed matches the one stored in the device.
+// The device configuration is not modified.
+// readPin and readWord are called each time the device asks for the PIN or for a seed word.
+// Returns true if the seed matches, along with the message reported by the device.
+// Any other failure than a mismatch, i.e. a cancelled action, is returned as a DeviceError.
+func (d *Device) VerifyBackup(wordCount uint32, usePassphrase *bool, readPin, readWord func() (string, error)) (bool, string, error) {
+	msg, err := d.Recovery(wordCount, usePassphrase, true)
+	if err != nil {
+		return false, "", err
+	}
+
+	for {
+		switch msg.Kind {
+		case uint16(messages.MessageType_MessageType_ButtonRequest):
+			msg, err = d.ButtonAck()
+		case uint16(messages.MessageType_MessageType_PinMatrixRequest):
+			var pin string
+			if pin, err = readPin(); err != nil {
+				return false, "", err
+			}
+			msg, err = d.PinMatrixAck(pin)
+		case uint16(messages.MessageType_MessageType_WordRequest):
+			var word string
+			if word, err = readWord(); err != nil {
+				return false, "", err
+			}
+			msg, err = d.WordAck(word)
+		case uint16(messages.MessageType_MessageType_Success):
+			successMsg, err := DecodeSuccessMsg(msg)
+			return err == nil, successMsg, err
+		case uint16(messages.MessageType_MessageType_Failure):
+			// the firmware reports a seed that does not match, or is invalid, as a data error
+			var deviceErr DeviceError
+			if err := DecodeFailure(msg); !errors.As(err, &deviceErr) || deviceErr.Code != messages.FailureType_Failure_DataError {
+				return false, "", err
+			}
+			return false, deviceErr.Message, nil
+		default:
+			return false, "", unexpectedMessage(msg, messages.MessageType_MessageType_Success, messages.MessageType_MessageType_Failure)
+		}
+		if err != nil {
+			return false, "", err
+		}
+	}
+}
+
 // SetMnemonic Configure the device with a mnemonic.
+// The mnemonic is checked to be a valid BIP-39 mnemonic before connecting to the device,
+// and sent normalized to lowercase words separated by single spaces.
 func (d *Device) SetMnemonic(mnemonic string) (wire.Message, error) {
+	mnemonic = bip39.NormalizeMnemonic(mnemonic)
+	if err := bip39.ValidateMnemonic(mnemonic); err != nil {
+		return wire.Message{}, err
+	}
+
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	// Send SetMnemonic
 	setMnemonicChunks, err := MessageSetMnemonic(mnemonic)
@@ -732,13 +792,15 @@ func (d *Device) SetMnemonic(mnemonic string) (wire.Message, error) {
 }
 
 // SignMessage Ask the device to sign a message using the secret key at given index.
-func (d *Device) SignMessage(addressIndex int, message string) (wire.Message, error) {
+// Decode the response with DecodeResponseSignMessage to get the signature in the coin type format.
+// An UnsupportedError is returned if the firmware does not know the bitcoin messages.
+func (d *Device) SignMessage(addressIndex int, message string, coinType CoinType) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
-	signMessageChunks, err := MessageSignMessage(addressIndex, message)
+	signMessageChunks, err := MessageSignMessage(addressIndex, message, coinType)
 	if err != nil {
 		return wire.Message{}, err
 	}
@@ -747,6 +809,9 @@ func (d *Device) SignMessage(addressIndex int, message string) (wire.Message, er
 	if err != nil {
 		return wire.Message{}, err
 	}
+	if coinType == BitcoinCoinType {
+		return msg, unsupportedFailure(msg, "bitcoin")
+	}
 
 	return msg, err
 }
@@ -756,7 +821,7 @@ func (d *Device) TransactionSign(inputs []*messages.SkycoinTransactionInput, out
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	var transactionInputs []*messages.TxAck_TransactionType_TxInputType
 	var transactionOutputs []*messages.TxAck_TransactionType_TxOutputType
@@ -789,17 +854,10 @@ func (d *Device) TransactionSign(inputs []*messages.SkycoinTransactionInput, out
 		return wire.Message{}, err
 	}
 	padding := false
-	data, err := proto.Marshal(&messages.ResponseTransactionSign{
+	return Encode(&messages.ResponseTransactionSign{
 		Padding:    &padding,
 		Signatures: signatures,
 	})
-	if err != nil {
-		return wire.Message{}, err
-	}
-	return wire.Message{
-		Kind: uint16(messages.MessageType_MessageType_ResponseTransactionSign),
-		Data: data,
-	}, nil
 }
 
 // GeneralTransactionSign Ask the device to sign a transaction using the given TransactionSigner
@@ -813,7 +871,7 @@ func (d *Device) SignTx(outputsCount int, inputsCount int, coinName string, vers
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	signTxChunks, err := MessageSignTx(outputsCount, inputsCount, coinName, version, lockTime, txHash)
 
@@ -829,7 +887,7 @@ func (d *Device) TxAck(inputs []*messages.TxAck_TransactionType_TxInputType, out
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 	txAckChunks, err := MessageTxAck(inputs, outputs, version, lockTime)
 	if err != nil {
 		return wire.Message{}, err
@@ -839,17 +897,22 @@ func (d *Device) TxAck(inputs []*messages.TxAck_TransactionType_TxInputType, out
 }
 
 // BitcoinTxAck ask the device to continue a Bitcoin long transaction using the given information.
+// An UnsupportedError is returned if the firmware does not know the bitcoin messages.
 func (d *Device) BitcoinTxAck(inputs []*messages.BitcoinTransactionInput, outputs []*messages.BitcoinTransactionOutput) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 	txAckChunks, err := BitcoinMessageTxAck(inputs, outputs)
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, txAckChunks)
+	msg, err := d.Driver.SendToDevice(d.dev, txAckChunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return msg, unsupportedFailure(msg, "bitcoin")
 }
 
 // Wipe wipes out device configuration
@@ -857,7 +920,7 @@ func (d *Device) Wipe() (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	wipeChunks, err := MessageWipe()
 	if err != nil {
@@ -878,7 +941,7 @@ func (d *Device) ButtonAck() (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	// Send ButtonAck
 	buttonChunks, err := MessageButtonAck()
@@ -898,54 +961,38 @@ func (d *Device) ButtonAck() (wire.Message, error) {
 		}
 	}
 
-	msg, err := wire.ReadFrom(d.dev)
+	msg, err := readMessage(d.dev)
 	if err != nil {
 		return wire.Message{}, err
 	}
-	for msg.Kind == uint16(messages.MessageType_MessageType_EntropyRequest) {
-		var wg sync.WaitGroup
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-			entropyChunks, err := MessageEntropyAck(entropyBufferSize)
-			if err != nil {
-				log.Errorf("failed to create entropy ack msg: %v", err)
-				return
-			}
-
-			for _, element := range entropyChunks {
-				_, err := d.dev.Write(element[:])
-				if err != nil {
-					log.Errorf("entropy ack error: %v", err)
-					return
-				}
-			}
-		}()
-
-		msg, err = wire.ReadFrom(d.dev)
-		if err != nil {
-			return wire.Message{}, err
-		}
-		wg.Wait()
+	msg, err = answerEntropyRequests(d.dev, msg, d.EntropySource())
+	if err != nil {
+		return wire.Message{}, err
 	}
 
 	return *msg, err
 }
 
-// PassphraseAck send this message when the device is waiting for the user to input a passphrase
+// PassphraseAck send this message when the device is waiting for the user to input a passphrase.
+// The passphrase state the device answers with is remembered for it. A WalletSwapError is
+// returned if the state differs from the one of a previous passphrase, that is if the
+// passphrase opened another hidden wallet.
 func (d *Device) PassphraseAck(passphrase string) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
-	passphraseChunks, err := MessagePassphraseAck(passphrase)
+	passphraseChunks, err := MessagePassphraseAckState(passphrase, d.passphraseStates.current())
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, passphraseChunks)
+	msg, err := d.Driver.SendToDevice(d.dev, passphraseChunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return d.answerPassphraseState(msg)
 }
 
 // WordAck send a word to the device during device "recovery procedure"
@@ -953,7 +1000,7 @@ func (d *Device) WordAck(word string) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
+	defer d.release()
 
 	wordAckChunks, err := MessageWordAck(word)
 	if err != nil {
@@ -969,9 +1016,7 @@ func (d *Device) PinMatrixAck(p string) (wire.Message, error) {
 	if err := d.Connect(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.Disconnect()
-
-	log.Printf("Setting pin: %s\n", p)
+	defer d.release()
 
 	pinMatrixChunks, err := MessagePinMatrixAck(p)
 	if err != nil {
@@ -981,6 +1026,45 @@ func (d *Device) PinMatrixAck(p string) (wire.Message, error) {
 	return d.Driver.SendToDevice(d.dev, pinMatrixChunks)
 }
 
+// awaitMessage answers the button, PIN and passphrase requests following msg
+// until the device sends a message of the expected kind, which is returned.
+// readPin and readPassphrase are called when the device asks for them, a nil
+// function makes the request fail. A device failure is returned as an error.
+func (d *Device) awaitMessage(msg wire.Message, kind messages.MessageType, readPin, readPassphrase func() (string, error)) (wire.Message, error) {
+	var err error
+	for {
+		switch msg.Kind {
+		case uint16(kind):
+			return msg, nil
+		case uint16(messages.MessageType_MessageType_ButtonRequest):
+			msg, err = d.ButtonAck()
+		case uint16(messages.MessageType_MessageType_PinMatrixRequest):
+			if readPin == nil {
+				return wire.Message{}, errors.New("device asked for a PIN code")
+			}
+			var pin string
+			if pin, err = readPin(); err != nil {
+				return wire.Message{}, err
+			}
+			msg, err = d.PinMatrixAck(pin)
+		case uint16(messages.MessageType_MessageType_PassphraseRequest):
+			if readPassphrase == nil {
+				return wire.Message{}, errors.New("device asked for a passphrase")
+			}
+			var passphrase string
+			if passphrase, err = readPassphrase(); err != nil {
+				return wire.Message{}, err
+			}
+			msg, err = d.PassphraseAck(passphrase)
+		default:
+			return wire.Message{}, unexpectedMessage(msg, kind)
+		}
+		if err != nil {
+			return wire.Message{}, err
+		}
+	}
+}
+
 // SimulateButtonPress simulates a button press on emulator
 func (d *Device) SimulateButtonPress() error {
 	if d.Driver.DeviceType() != DeviceTypeEmulator {
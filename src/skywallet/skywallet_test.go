@@ -5,8 +5,10 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/gogo/protobuf/proto"
 	messages "github.com/skycoin/hardware-wallet-protob/go"
 
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/bip39"
 	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
 
 	"github.com/stretchr/testify/require"
@@ -40,6 +42,113 @@ func (cwr testHelperCloseableBuffer) Close(disconnect bool) error {
 	return nil
 }
 
+func (suite *devicerSuit) TestAddressGenBitcoinUnsupported() {
+	unknown, err := Encode(&messages.Failure{
+		Code:    messages.FailureType_Failure_UnexpectedMessage.Enum(),
+		Message: proto.String("Unknown message"),
+	})
+	suite.Require().NoError(err)
+	invalidPin, err := Encode(&messages.Failure{
+		Code:    messages.FailureType_Failure_PinInvalid.Enum(),
+		Message: proto.String("Invalid PIN"),
+	})
+	suite.Require().NoError(err)
+
+	tt := []struct {
+		name     string
+		coinType CoinType
+		answer   wire.Message
+		err      error
+	}{
+		{
+			name:     "firmware without bitcoin",
+			coinType: BitcoinCoinType,
+			answer:   unknown,
+			err:      UnsupportedError{Feature: "bitcoin"},
+		},
+		{
+			name:     "other failure",
+			coinType: BitcoinCoinType,
+			answer:   invalidPin,
+		},
+		{
+			name:     "skycoin",
+			coinType: SkycoinCoinType,
+			answer:   unknown,
+		},
+	}
+
+	for _, tc := range tt {
+		driverMock := &MockDeviceDriver{}
+		driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+		driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(tc.answer, nil)
+		device := getMockDevice(driverMock)
+
+		msg, err := device.AddressGen(1, 0, false, tc.coinType)
+		suite.Equal(tc.err, err, tc.name)
+		suite.Equal(tc.answer, msg, tc.name)
+	}
+}
+
+func (suite *devicerSuit) TestBitcoinUnsupported() {
+	unknown, err := Encode(&messages.Failure{
+		Code:    messages.FailureType_Failure_UnexpectedMessage.Enum(),
+		Message: proto.String("Unknown message"),
+	})
+	suite.Require().NoError(err)
+	txRequest, err := Encode(&messages.TxRequest{RequestType: messages.TxRequest_TXOUTPUT.Enum()})
+	suite.Require().NoError(err)
+	signer := func() *BitcoinTransactionSigner {
+		return &BitcoinTransactionSigner{
+			Inputs:  []*messages.BitcoinTransactionInput{{AddressN: proto.Uint32(0), PrevHash: []byte("hash")}},
+			Outputs: []*messages.BitcoinTransactionOutput{{Address: proto.String("address"), Coin: proto.Uint64(1)}},
+		}
+	}
+
+	tt := []struct {
+		name    string
+		answers []wire.Message
+		call    func(d *Device) error
+	}{
+		{
+			name:    "sign message",
+			answers: []wire.Message{unknown},
+			call: func(d *Device) error {
+				_, err := d.SignMessage(0, "hello", BitcoinCoinType)
+				return err
+			},
+		},
+		{
+			name:    "sign transaction",
+			answers: []wire.Message{unknown},
+			call: func(d *Device) error {
+				_, err := d.GeneralTransactionSign(signer())
+				return err
+			},
+		},
+		{
+			name:    "transaction outputs",
+			answers: []wire.Message{txRequest, unknown},
+			call: func(d *Device) error {
+				_, err := d.GeneralTransactionSign(signer())
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		driverMock := &MockDeviceDriver{}
+		driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+		for _, answer := range tc.answers {
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(answer, nil).Once()
+		}
+		device := getMockDevice(driverMock)
+
+		suite.Equal(UnsupportedError{Feature: "bitcoin"}, tc.call(&device), tc.name)
+		driverMock.AssertNumberOfCalls(suite.T(), "SendToDevice", len(tc.answers))
+	}
+}
+
 func (suite *devicerSuit) TestAddressGen() {
 	driverMock := &MockDeviceDriver{}
 	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
@@ -275,6 +384,85 @@ func (suite *devicerSuit) TestRecovery() {
 	mock.AssertExpectationsForObjects(suite.T(), driverMock)
 }
 
+func (suite *devicerSuit) TestVerifyBackup() {
+	tt := []struct {
+		name     string
+		lastKind messages.MessageType
+		lastMsg  proto.Message
+		match    bool
+		msg      string
+		err      error
+	}{
+		{
+			name:     "seed matches",
+			lastKind: messages.MessageType_MessageType_Success,
+			lastMsg:  &messages.Success{Message: proto.String("The seed is valid and matches the one in the device")},
+			match:    true,
+			msg:      "The seed is valid and matches the one in the device",
+		},
+		{
+			name:     "seed does not match",
+			lastKind: messages.MessageType_MessageType_Failure,
+			lastMsg: &messages.Failure{
+				Code:    messages.FailureType_Failure_DataError.Enum(),
+				Message: proto.String("The seed is valid but does not match the one in the device"),
+			},
+			match: false,
+			msg:   "The seed is valid but does not match the one in the device",
+		},
+		{
+			name:     "cancelled",
+			lastKind: messages.MessageType_MessageType_Failure,
+			lastMsg: &messages.Failure{
+				Code:    messages.FailureType_Failure_ActionCancelled.Enum(),
+				Message: proto.String("Action cancelled by user"),
+			},
+			err: DeviceError{Code: messages.FailureType_Failure_ActionCancelled, Message: "Action cancelled by user"},
+		},
+		{
+			name:     "invalid PIN",
+			lastKind: messages.MessageType_MessageType_Failure,
+			lastMsg: &messages.Failure{
+				Code:    messages.FailureType_Failure_PinInvalid.Enum(),
+				Message: proto.String("Invalid PIN"),
+			},
+			err: DeviceError{Code: messages.FailureType_Failure_PinInvalid, Message: "Invalid PIN"},
+		},
+	}
+
+	for _, tc := range tt {
+		// NOTE: Giving
+		lastData, err := proto.Marshal(tc.lastMsg)
+		suite.NoError(err)
+		driverMock := &MockDeviceDriver{}
+		driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+		driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+			wire.Message{Kind: uint16(messages.MessageType_MessageType_WordRequest)}, nil).Twice()
+		driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+			wire.Message{Kind: uint16(tc.lastKind), Data: lastData}, nil).Once()
+		device := getMockDevice(driverMock)
+		var words []string
+		readWord := func() (string, error) {
+			words = append(words, "zoo")
+			return "zoo", nil
+		}
+		readPin := func() (string, error) {
+			suite.FailNow("PIN not requested")
+			return "", nil
+		}
+
+		// NOTE: When
+		match, msg, err := device.VerifyBackup(12, nil, readPin, readWord)
+
+		// NOTE: Assert
+		suite.Equal(tc.err, err, tc.name)
+		suite.Equal(tc.match, match, tc.name)
+		suite.Equal(tc.msg, msg, tc.name)
+		suite.Len(words, 2, tc.name)
+		driverMock.AssertNumberOfCalls(suite.T(), "SendToDevice", 3)
+	}
+}
+
 func (suite *devicerSuit) TestSetMnemonic() {
 	// NOTE(denisacostaq@gmail.com): Giving
 	driverMock := &MockDeviceDriver{}
@@ -294,6 +482,41 @@ func (suite *devicerSuit) TestSetMnemonic() {
 	require.Equal(suite.T(), msg.Kind, uint16(messages.MessageType_MessageType_Success))
 }
 
+func (suite *devicerSuit) TestSetMnemonicInvalid() {
+	// NOTE: Giving
+	driverMock := &MockDeviceDriver{}
+	device := getMockDevice(driverMock)
+
+	// NOTE: When
+	_, errChecksum := device.SetMnemonic("cloud flower upset remain green metal below cup stem infant art art")
+	_, errWordCount := device.SetMnemonic("cloud flower upset remain green metal below cup stem infant art")
+
+	// NOTE: Assert
+	suite.Equal(bip39.ErrChecksumMismatch, errChecksum)
+	suite.Equal(bip39.ErrInvalidWordCount, errWordCount)
+	// the device is not claimed for an invalid mnemonic
+	driverMock.AssertNotCalled(suite.T(), "GetDevice")
+	driverMock.AssertNotCalled(suite.T(), "SendToDevice", mock.Anything, mock.Anything)
+}
+
+func (suite *devicerSuit) TestSetMnemonicNormalized() {
+	// NOTE: Giving
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	expected, err := MessageSetMnemonic("cloud flower upset remain green metal below cup stem infant art thank")
+	suite.NoError(err)
+	driverMock.On("SendToDevice", mock.Anything, expected).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Success), Data: nil}, nil)
+	device := getMockDevice(driverMock)
+
+	// NOTE: When
+	_, err = device.SetMnemonic("  Cloud flower upset remain\tgreen metal below cup stem  infant art THANK\n")
+
+	// NOTE: Assert
+	suite.NoError(err)
+	driverMock.AssertNumberOfCalls(suite.T(), "SendToDevice", 1)
+}
+
 func (suite *devicerSuit) TestRemovePinCode() {
 	driverMock := &MockDeviceDriver{}
 	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
@@ -370,5 +593,5 @@ func (suite *devicerSuit) TestWipe() {
 }
 
 func getMockDevice(mock *MockDeviceDriver) Device {
-	return Device{mock, sync.Mutex{}, nil, false, false, ButtonType(-1)}
+	return Device{mock, sync.Mutex{}, nil, false, false, ButtonType(-1), entropySourceHolder{}, passphraseStates{}, 0}
 }
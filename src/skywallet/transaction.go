@@ -2,9 +2,6 @@ package skywallet
 
 import (
 	"errors"
-	"fmt"
-
-	"github.com/gogo/protobuf/proto"
 
 	messages "github.com/skycoin/hardware-wallet-protob/go"
 
@@ -25,11 +22,11 @@ var (
 	//ErrInvalidIndex is returned if inputs doesn't have such indexes
 	ErrInvalidIndex = errors.New("invalid index or count")
 	//ErrUnexpectedTxinput is returned if TXINPUT was received, but not expected for finite-state machine
-	ErrUnexpectedTxinput = errors.New("protocol error: unexpected TXINPUT")
+	ErrUnexpectedTxinput = ProtocolError{Err: errors.New("unexpected TXINPUT")}
 	//ErrUnexpectedTxoutput is returned if TXOUTPUT was received, but not expected for finite-state machine
-	ErrUnexpectedTxoutput = errors.New("protocol error: unexpected TXOUTPUT")
+	ErrUnexpectedTxoutput = ProtocolError{Err: errors.New("unexpected TXOUTPUT")}
 	//ErrUnexpectedTxfinished is returned if TXFINISHED was received, but not expected for finite-state machine
-	ErrUnexpectedTxfinished = errors.New("protocol error: unexpected TXFINISHED")
+	ErrUnexpectedTxfinished = ProtocolError{Err: errors.New("unexpected TXFINISHED")}
 )
 
 // SkycoinTransactionSigner represents signing Skycoin transaction process
@@ -64,19 +61,19 @@ func (s *SkycoinTransactionSigner) Sign() ([]string, error) {
 		switch msg.Kind {
 		case uint16(messages.MessageType_MessageType_TxRequest):
 			txRequest := &messages.TxRequest{}
-			err = proto.Unmarshal(msg.Data, txRequest)
+			err = decodeAs(msg, txRequest)
 			if err != nil {
 				return nil, err
 			}
 			switch *txRequest.RequestType {
 			case messages.TxRequest_TXINPUT:
 				if s.state == 0 { // Sending Inputs for InnerHash
-					if len(s.Inputs)-index > 8 {
-						msg, err = s.sendInputs(index, 8)
+					if len(s.Inputs)-index > txBatchSize {
+						msg, err = s.sendInputs(index, txBatchSize)
 						if err != nil {
 							return nil, err
 						}
-						index += 8
+						index += txBatchSize
 					} else {
 						msg, err = s.sendInputs(index, len(s.Inputs)-index)
 						if err != nil {
@@ -90,8 +87,8 @@ func (s *SkycoinTransactionSigner) Sign() ([]string, error) {
 					if err != nil {
 						return nil, err
 					}
-					if len(s.Inputs)-index > 8 {
-						msg, err = s.sendInputs(index, 8)
+					if len(s.Inputs)-index > txBatchSize {
+						msg, err = s.sendInputs(index, txBatchSize)
 						if err != nil {
 							return nil, err
 						}
@@ -103,18 +100,18 @@ func (s *SkycoinTransactionSigner) Sign() ([]string, error) {
 						s.state++
 						index = 0
 					}
-					index += 8
+					index += txBatchSize
 				} else {
 					return nil, ErrUnexpectedTxinput
 				}
 			case messages.TxRequest_TXOUTPUT:
 				if s.state == 1 { // Sending Outputs for InnerHash
-					if len(s.Outputs)-index > 8 {
-						msg, err = s.sendOutputs(index, 8)
+					if len(s.Outputs)-index > txBatchSize {
+						msg, err = s.sendOutputs(index, txBatchSize)
 						if err != nil {
 							return nil, err
 						}
-						index += 8
+						index += txBatchSize
 					} else {
 						msg, err = s.sendOutputs(index, len(s.Outputs)-index)
 						if err != nil {
@@ -136,19 +133,13 @@ func (s *SkycoinTransactionSigner) Sign() ([]string, error) {
 				}
 				return nil, ErrUnexpectedTxfinished
 			}
-		case uint16(messages.MessageType_MessageType_Failure):
-			failMsg, err := DecodeFailMsg(msg)
-			if err != nil {
-				return nil, err
-			}
-			return nil, fmt.Errorf("failed with message: %s", failMsg)
 		case uint16(messages.MessageType_MessageType_ButtonRequest):
 			msg, err = s.Device.ButtonAck()
 			if err != nil {
 				return nil, err
 			}
 		default:
-			return nil, fmt.Errorf("unexpected response message type from hardware wallet")
+			return nil, unexpectedMessage(msg, messages.MessageType_MessageType_TxRequest, messages.MessageType_MessageType_ButtonRequest)
 		}
 	}
 }
@@ -184,7 +175,7 @@ func (s *SkycoinTransactionSigner) sendOutputs(startIndex, count int) (wire.Mess
 
 func (s *SkycoinTransactionSigner) addSignatures(msg *wire.Message) error {
 	txRequest := &messages.TxRequest{}
-	err := proto.Unmarshal(msg.Data, txRequest)
+	err := decodeAs(*msg, txRequest)
 	if err != nil {
 		return err
 	}
@@ -227,19 +218,19 @@ func (s *BitcoinTransactionSigner) Sign() ([]string, error) {
 		switch msg.Kind {
 		case uint16(messages.MessageType_MessageType_TxRequest):
 			txRequest := &messages.TxRequest{}
-			err = proto.Unmarshal(msg.Data, txRequest)
+			err = decodeAs(msg, txRequest)
 			if err != nil {
 				return nil, err
 			}
 			switch *txRequest.RequestType {
 			case messages.TxRequest_TXOUTPUT:
 				if s.state == 0 { // Sending Outputs for Confirmation
-					if len(s.Outputs)-index > 8 {
-						msg, err = s.sendOutputs(index, 8)
+					if len(s.Outputs)-index > txBatchSize {
+						msg, err = s.sendOutputs(index, txBatchSize)
 						if err != nil {
 							return nil, err
 						}
-						index += 8
+						index += txBatchSize
 					} else {
 						msg, err = s.sendOutputs(index, len(s.Outputs)-index)
 						if err != nil {
@@ -257,8 +248,8 @@ func (s *BitcoinTransactionSigner) Sign() ([]string, error) {
 					if err != nil {
 						return nil, err
 					}
-					if len(s.Inputs)-index > 8 {
-						msg, err = s.sendInputs(index, 8)
+					if len(s.Inputs)-index > txBatchSize {
+						msg, err = s.sendInputs(index, txBatchSize)
 						if err != nil {
 							return nil, err
 						}
@@ -270,7 +261,7 @@ func (s *BitcoinTransactionSigner) Sign() ([]string, error) {
 						s.state++
 						index = 0
 					}
-					index += 8
+					index += txBatchSize
 				} else {
 					return nil, ErrUnexpectedTxinput
 				}
@@ -284,22 +275,20 @@ func (s *BitcoinTransactionSigner) Sign() ([]string, error) {
 				}
 				return nil, ErrUnexpectedTxfinished
 			}
-		case uint16(messages.MessageType_MessageType_Failure):
-			failMsg, err := DecodeFailMsg(msg)
-			if err != nil {
-				return nil, err
-			}
-			return nil, fmt.Errorf("Failed with message: %s", failMsg)
 		case uint16(messages.MessageType_MessageType_ButtonRequest):
 			msg, err = s.Device.ButtonAck()
 		default:
-			return nil, fmt.Errorf("unexpected response message type from hardware wallet")
+			return nil, unexpectedMessage(msg, messages.MessageType_MessageType_TxRequest, messages.MessageType_MessageType_ButtonRequest)
 		}
 	}
 }
 
 func (s *BitcoinTransactionSigner) initSigningProcess() (wire.Message, error) {
-	return s.Device.SignTx(len(s.Outputs), len(s.Inputs), "Bitcoin", s.Version, s.LockTime, "dkdji9e2oidhash")
+	msg, err := s.Device.SignTx(len(s.Outputs), len(s.Inputs), "Bitcoin", s.Version, s.LockTime, "dkdji9e2oidhash")
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return msg, unsupportedFailure(msg, "bitcoin")
 }
 
 func (s *BitcoinTransactionSigner) sendInputs(startIndex, count int) (wire.Message, error) {
@@ -328,7 +317,7 @@ func (s *BitcoinTransactionSigner) sendOutputs(startIndex, count int) (wire.Mess
 
 func (s *BitcoinTransactionSigner) addSignatures(msg *wire.Message) error {
 	txRequest := &messages.TxRequest{}
-	err := proto.Unmarshal(msg.Data, txRequest)
+	err := decodeAs(*msg, txRequest)
 	if err != nil {
 		return err
 	}
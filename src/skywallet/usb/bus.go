@@ -24,6 +24,8 @@ var (
 	ErrNotFound     = errors.New("device not found")
 	ErrDisconnect   = errors.New("device disconnected during action")
 	ErrClosedDevice = errors.New("closed device")
+	// ErrPermissionDenied is returned if the user is not allowed to open the device
+	ErrPermissionDenied = errors.New("permission denied")
 )
 
 type DeviceType int
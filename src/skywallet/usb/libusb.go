@@ -2,6 +2,7 @@ package usb
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -103,7 +104,7 @@ func (b *LibUSB) Enumerate(vendorID, productID uint16) ([]Info, error) {
 	list, err := lowlevel.Get_Device_List_Filtered(b.usb, vendorID, productID)
 
 	if err != nil {
-		return nil, err
+		return nil, libusbError(err)
 	}
 
 	defer func() {
@@ -172,7 +173,7 @@ func (b *LibUSB) Connect(path string) (Device, error) {
 		return b.matchVidPid(vid, pid)
 	})
 	if err != nil && len(list) == 0 {
-		return nil, err
+		return nil, libusbError(err)
 	}
 
 	// Find the device with matching path
@@ -200,11 +201,19 @@ func (b *LibUSB) Connect(path string) (Device, error) {
 	res, errConn := b.connect(foundDev)
 	if errConn != nil {
 		foundDev.Close()
-		return nil, errConn
+		return nil, libusbError(errConn)
 	}
 	return res, nil
 }
 
+// libusbError wraps the libusb access errors in ErrPermissionDenied
+func libusbError(err error) error {
+	if errors.Is(err, gousb.ErrorAccess) {
+		return fmt.Errorf("%w: %v", ErrPermissionDenied, err)
+	}
+	return err
+}
+
 func (b *LibUSB) setConfiguration(d lowlevel.Device_Handle) (*gousb.Config, error) {
 	currConf, err := lowlevel.Get_Configuration(d)
 	if err != nil {
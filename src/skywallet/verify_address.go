@@ -0,0 +1,129 @@
+package skywallet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+)
+
+var (
+	// ErrAddressMismatch is returned if the device confirms an address other than the one shown on the host
+	ErrAddressMismatch = errors.New("address confirmed by the device does not match the address shown on the host")
+)
+
+// AddressVerification records the check of a receive address against the device screen
+type AddressVerification struct {
+	Time            time.Time `json:"time"`
+	DeviceID        string    `json:"device_id"`
+	Label           string    `json:"label,omitempty"`
+	FirmwareVersion string    `json:"firmware_version,omitempty"`
+	CoinType        string    `json:"coin_type"`
+	AddressIndex    uint32    `json:"address_index"`
+	Address         string    `json:"address"`
+	// DeviceConfirmed is true if the user accepted the address on the device
+	// and the device returned the address shown on the host
+	DeviceConfirmed bool `json:"device_confirmed"`
+	// UserMatch is the user answer to whether the device screen showed the host address
+	UserMatch bool `json:"user_match"`
+	// Error tells why the verification failed, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// Verified tells whether both the device and the user confirmed the address
+func (v AddressVerification) Verified() bool {
+	return v.DeviceConfirmed && v.UserMatch
+}
+
+// VerifyAddress derives the address at addressIndex, passes it to show so the host
+// displays it and then asks the device to display it too, waiting for the user to
+// accept it on the device. readPin and readPassphrase are called when the device
+// asks for them.
+// Once the address has been shown the verification is returned even with an error,
+// so that failed checks can be recorded too. ErrAddressMismatch is returned if the
+// device confirms another address. The caller fills UserMatch.
+func (d *Device) VerifyAddress(addressIndex uint32, coinType CoinType, show func(address string) error, readPin, readPassphrase func() (string, error)) (*AddressVerification, error) {
+	info, err := d.DeviceInfo()
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := d.AddressDeriver(coinType, readPin, readPassphrase)(addressIndex, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != 1 {
+		return nil, errors.New("device did not return the address")
+	}
+
+	verification := &AddressVerification{
+		Time:         time.Now().UTC(),
+		DeviceID:     info.DeviceID,
+		Label:        info.Label,
+		CoinType:     coinType.String(),
+		AddressIndex: addressIndex,
+		Address:      addresses[0],
+	}
+	if info.FirmwareVersion != nil {
+		verification.FirmwareVersion = info.FirmwareVersion.String()
+	}
+
+	if err := show(verification.Address); err != nil {
+		return nil, err
+	}
+
+	confirmed, err := d.confirmAddress(addressIndex, coinType, readPin, readPassphrase)
+	if err == nil && confirmed != verification.Address {
+		err = ErrAddressMismatch
+	}
+	if err != nil {
+		verification.Error = err.Error()
+		return verification, err
+	}
+	verification.DeviceConfirmed = true
+	return verification, nil
+}
+
+// confirmAddress asks the device to display the address at addressIndex
+// and returns it once the user accepts it
+func (d *Device) confirmAddress(addressIndex uint32, coinType CoinType, readPin, readPassphrase func() (string, error)) (string, error) {
+	msg, err := d.AddressGen(1, addressIndex, true, coinType)
+	if err != nil {
+		return "", err
+	}
+	msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_ResponseSkycoinAddress, readPin, readPassphrase)
+	if err != nil {
+		return "", err
+	}
+	addresses, err := DecodeResponseSkycoinAddress(msg)
+	if err != nil {
+		return "", err
+	}
+	if len(addresses) != 1 {
+		return "", errors.New("device did not return the address")
+	}
+	return addresses[0], nil
+}
+
+// AppendAddressVerification appends the verification as a JSON line to the audit log at path
+func AppendAddressVerification(path string, verification AddressVerification) error {
+	b, err := json.Marshal(verification)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,108 @@
+package skywallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+func TestVerifyAddress(t *testing.T) {
+	features, err := proto.Marshal(&messages.Features{DeviceId: proto.String("ABCD"), Label: proto.String("treasury")})
+	require.NoError(t, err)
+	address, err := proto.Marshal(&messages.ResponseSkycoinAddress{Addresses: []string{"addr0"}})
+	require.NoError(t, err)
+	otherAddress, err := proto.Marshal(&messages.ResponseSkycoinAddress{Addresses: []string{"addr1"}})
+	require.NoError(t, err)
+	cancelled, err := proto.Marshal(&messages.Failure{Message: proto.String("Action cancelled by user")})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		confirmed wire.Message
+		err       string
+	}{
+		{
+			name:      "confirmed",
+			confirmed: wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: address},
+		},
+		{
+			name:      "other address",
+			confirmed: wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: otherAddress},
+			err:       ErrAddressMismatch.Error(),
+		},
+		{
+			name:      "rejected on device",
+			confirmed: wire.Message{Kind: uint16(messages.MessageType_MessageType_Failure), Data: cancelled},
+			err:       "Action cancelled by user",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			driverMock := &MockDeviceDriver{}
+			driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+				wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: features}, nil).Once()
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+				wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: address}, nil).Once()
+			driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(tc.confirmed, nil).Once()
+			device := getMockDevice(driverMock)
+
+			var shown string
+			v, err := device.VerifyAddress(3, SkycoinCoinType, func(address string) error {
+				shown = address
+				return nil
+			}, nil, nil)
+			require.Equal(t, "addr0", shown)
+			require.NotNil(t, v)
+			require.Equal(t, "ABCD", v.DeviceID)
+			require.Equal(t, "treasury", v.Label)
+			require.Equal(t, "SKY", v.CoinType)
+			require.Equal(t, uint32(3), v.AddressIndex)
+			require.Equal(t, "addr0", v.Address)
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.True(t, v.DeviceConfirmed)
+				require.Empty(t, v.Error)
+			} else {
+				require.EqualError(t, err, tc.err)
+				require.False(t, v.DeviceConfirmed)
+				require.Equal(t, tc.err, v.Error)
+			}
+			require.False(t, v.Verified())
+		})
+	}
+}
+
+func TestAppendAddressVerification(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verifyaddress")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "address-verification.log")
+
+	require.NoError(t, AppendAddressVerification(path, AddressVerification{Address: "addr0", DeviceConfirmed: true, UserMatch: true}))
+	require.NoError(t, AppendAddressVerification(path, AddressVerification{Address: "addr1", Error: ErrAddressMismatch.Error()}))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+
+	var v AddressVerification
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &v))
+	require.Equal(t, "addr0", v.Address)
+	require.True(t, v.Verified())
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &v))
+	require.Equal(t, "addr1", v.Address)
+	require.False(t, v.Verified())
+}
@@ -0,0 +1,131 @@
+package skywallet
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// watchOnlyWalletVersion is the version of the Skycoin .wlt file layout written by ExportWatchOnly
+const watchOnlyWalletVersion = "0.2"
+
+// publicKeyMessage is signed with each exported address to recover its public key,
+// the device has no message returning public keys
+const publicKeyMessage = "skycoin hardware wallet watch only export"
+
+// PublicKeyMismatchError is returned if the public key recovered for an address does not match it
+type PublicKeyMismatchError struct {
+	Address   string
+	Recovered string
+}
+
+func (e PublicKeyMismatchError) Error() string {
+	return fmt.Sprintf("the public key signed for %s belongs to %s", e.Address, e.Recovered)
+}
+
+// WatchOnlyWallet is a Skycoin .wlt collection wallet holding device addresses and
+// their public keys without any secret. Signing stays on the device, the wallet is
+// only useful to monitor balances.
+type WatchOnlyWallet struct {
+	Meta    map[string]string `json:"meta"`
+	Entries []WatchOnlyEntry  `json:"entries"`
+}
+
+// WatchOnlyEntry is an address of a WatchOnlyWallet, its secret key is always empty
+type WatchOnlyEntry struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// NewWatchOnlyWallet builds a watch only wallet named filename with the addresses of
+// pubKeys, which belong to the device described by info. The wallet label is the
+// device label and the wallet meta is tagged with the device id.
+func NewWatchOnlyWallet(filename string, info DeviceInfo, pubKeys []cipher.PubKey, created time.Time) *WatchOnlyWallet {
+	w := &WatchOnlyWallet{
+		Meta: map[string]string{
+			"coin":       "skycoin",
+			"filename":   filename,
+			"label":      info.Label,
+			"type":       "collection",
+			"version":    watchOnlyWalletVersion,
+			"tm":         strconv.FormatInt(created.Unix(), 10),
+			"encrypted":  "false",
+			"cryptoType": "",
+			"seed":       "",
+			"lastSeed":   "",
+			"secrets":    "",
+			"deviceId":   info.DeviceID,
+		},
+		Entries: make([]WatchOnlyEntry, 0, len(pubKeys)),
+	}
+	for _, pubKey := range pubKeys {
+		w.Entries = append(w.Entries, WatchOnlyEntry{
+			Address:   cipher.AddressFromPubKey(pubKey).String(),
+			PublicKey: pubKey.Hex(),
+		})
+	}
+	return w
+}
+
+// ExportWatchOnly asks the device its first n skycoin addresses and returns them
+// as a watch only wallet named filename. The device signs publicKeyMessage with each
+// address to reveal its public key, which may ask for a confirmation per address.
+// readPin and readPassphrase are called when the device asks for them.
+func (d *Device) ExportWatchOnly(filename string, n uint32, readPin, readPassphrase func() (string, error)) (*WatchOnlyWallet, error) {
+	if n == 0 {
+		return nil, ErrAddressNZero
+	}
+
+	info, err := d.DeviceInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := d.AddressDeriver(SkycoinCoinType, readPin, readPassphrase)(0, n)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeys := make([]cipher.PubKey, 0, len(addresses))
+	for i, address := range addresses {
+		pubKey, err := d.publicKey(uint32(i), address, readPin, readPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return NewWatchOnlyWallet(filename, *info, pubKeys, time.Now()), nil
+}
+
+// publicKey recovers the public key of the address at index from a signature of publicKeyMessage
+func (d *Device) publicKey(index uint32, address string, readPin, readPassphrase func() (string, error)) (cipher.PubKey, error) {
+	msg, err := d.SignMessage(int(index), publicKeyMessage, SkycoinCoinType)
+	if err != nil {
+		return cipher.PubKey{}, err
+	}
+	msg, err = d.awaitMessage(msg, messages.MessageType_MessageType_ResponseSkycoinSignMessage, readPin, readPassphrase)
+	if err != nil {
+		return cipher.PubKey{}, err
+	}
+	signature, err := DecodeResponseSkycoinSignMessage(msg)
+	if err != nil {
+		return cipher.PubKey{}, err
+	}
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return cipher.PubKey{}, err
+	}
+
+	pubKey, err := cipher.PubKeyFromSig(sig, MessageHash(publicKeyMessage))
+	if err != nil {
+		return cipher.PubKey{}, err
+	}
+	if recovered := cipher.AddressFromPubKey(pubKey).String(); recovered != address {
+		return cipher.PubKey{}, PublicKeyMismatchError{Address: address, Recovered: recovered}
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,146 @@
+package skywallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/skywallet/wire"
+)
+
+// requireLoadableWallet checks the wallet file at path the way the skycoin wallet
+// loader (src/wallet Load of skycoin v0.27.1) checks a collection wallet, which is
+// not part of the vendored skycoin packages
+func requireLoadableWallet(t *testing.T, path string) {
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var w struct {
+		Meta    map[string]string `json:"meta"`
+		Entries []struct {
+			Address     string  `json:"address"`
+			Public      string  `json:"public_key"`
+			Secret      string  `json:"secret_key"`
+			ChildNumber *uint32 `json:"child_number,omitempty"`
+			Change      *uint32 `json:"change,omitempty"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(b, &w))
+
+	// Meta.validate
+	require.NotEmpty(t, w.Meta["filename"])
+	_, err = strconv.ParseInt(w.Meta["tm"], 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, "collection", w.Meta["type"])
+	require.Equal(t, "skycoin", w.Meta["coin"])
+	encrypted, err := strconv.ParseBool(w.Meta["encrypted"])
+	require.NoError(t, err)
+	require.False(t, encrypted)
+	require.Empty(t, w.Meta["secrets"])
+	require.Empty(t, w.Meta["seed"])
+	require.Empty(t, w.Meta["lastSeed"])
+
+	// ReadableEntries.toWalletEntries
+	for _, e := range w.Entries {
+		address, err := cipher.DecodeBase58Address(e.Address)
+		require.NoError(t, err)
+		pubKey, err := cipher.PubKeyFromHex(e.Public)
+		require.NoError(t, err)
+		require.Empty(t, e.Secret)
+		require.Nil(t, e.ChildNumber)
+		require.Nil(t, e.Change)
+		require.Equal(t, address, cipher.AddressFromPubKey(pubKey))
+	}
+}
+
+// testKeys returns n deterministic key pairs
+func testKeys(t *testing.T, n int) ([]cipher.PubKey, []cipher.SecKey) {
+	secKeys, err := cipher.GenerateDeterministicKeyPairs([]byte("watch only"), n)
+	require.NoError(t, err)
+	pubKeys := make([]cipher.PubKey, n)
+	for i, secKey := range secKeys {
+		pubKeys[i], err = cipher.PubKeyFromSecKey(secKey)
+		require.NoError(t, err)
+	}
+	return pubKeys, secKeys
+}
+
+func TestNewWatchOnlyWallet(t *testing.T) {
+	pubKeys, _ := testKeys(t, 2)
+	info := DeviceInfo{DeviceID: "ABCD", Label: "treasury"}
+	w := NewWatchOnlyWallet("treasury.wlt", info, pubKeys, time.Unix(1540305209, 0))
+
+	b, err := json.Marshal(w)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "treasury.wlt")
+	require.NoError(t, ioutil.WriteFile(path, b, 0600))
+	requireLoadableWallet(t, path)
+
+	require.Equal(t, "treasury.wlt", w.Meta["filename"])
+	require.Equal(t, "treasury", w.Meta["label"])
+	require.Equal(t, "ABCD", w.Meta["deviceId"])
+	require.Equal(t, "1540305209", w.Meta["tm"])
+	require.Equal(t, WatchOnlyEntry{
+		Address:   cipher.AddressFromPubKey(pubKeys[1]).String(),
+		PublicKey: pubKeys[1].Hex(),
+	}, w.Entries[1])
+}
+
+func TestExportWatchOnly(t *testing.T) {
+	pubKeys, secKeys := testKeys(t, 2)
+	features, err := proto.Marshal(&messages.Features{DeviceId: proto.String("ABCD"), Label: proto.String("treasury")})
+	require.NoError(t, err)
+	addresses, err := proto.Marshal(&messages.ResponseSkycoinAddress{Addresses: []string{
+		cipher.AddressFromPubKey(pubKeys[0]).String(),
+		cipher.AddressFromPubKey(pubKeys[1]).String(),
+	}})
+	require.NoError(t, err)
+	signature := func(secKey cipher.SecKey) wire.Message {
+		sig, err := cipher.SignHash(MessageHash(publicKeyMessage), secKey)
+		require.NoError(t, err)
+		data, err := proto.Marshal(&messages.ResponseSkycoinSignMessage{SignedMessage: proto.String(sig.Hex())})
+		require.NoError(t, err)
+		return wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage), Data: data}
+	}
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(&testHelperCloseableBuffer{}, nil)
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: features}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: addresses}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(signature(secKeys[0]), nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(signature(secKeys[1]), nil).Once()
+	device := getMockDevice(driverMock)
+
+	w, err := device.ExportWatchOnly("treasury.wlt", 2, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "ABCD", w.Meta["deviceId"])
+	require.Equal(t, []WatchOnlyEntry{
+		{Address: cipher.AddressFromPubKey(pubKeys[0]).String(), PublicKey: pubKeys[0].Hex()},
+		{Address: cipher.AddressFromPubKey(pubKeys[1]).String(), PublicKey: pubKeys[1].Hex()},
+	}, w.Entries)
+
+	// a signature made by another key than the address one is rejected
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_Features), Data: features}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(
+		wire.Message{Kind: uint16(messages.MessageType_MessageType_ResponseSkycoinAddress), Data: addresses}, nil).Once()
+	driverMock.On("SendToDevice", mock.Anything, mock.Anything).Return(signature(secKeys[1]), nil).Once()
+	_, err = device.ExportWatchOnly("treasury.wlt", 2, nil, nil)
+	require.Equal(t, PublicKeyMismatchError{
+		Address:   cipher.AddressFromPubKey(pubKeys[0]).String(),
+		Recovered: cipher.AddressFromPubKey(pubKeys[1]).String(),
+	}, err)
+
+	_, err = device.ExportWatchOnly("treasury.wlt", 0, nil, nil)
+	require.Equal(t, ErrAddressNZero, err)
+}
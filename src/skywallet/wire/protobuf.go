@@ -8,6 +8,8 @@ import (
 
 const (
 	ioSeekCurrent = 1 // We define this constant (instead of using directly io.SeekCurrent) to be compatible with go1.6
+
+	maxFieldSize = 1024 * 1024 * 4 // 4mb field size
 )
 
 var (
@@ -16,9 +18,8 @@ var (
 
 func Validate(buf []byte) error {
 	const (
-		wireVarint   = 0               // int32, int64, uint32, uint64, sint32, sint64, bool, enum
-		wireData     = 2               // string, bytes, embedded messages, packed repeated fields
-		maxFieldSize = 1024 * 1024 * 4 // 4mb field size
+		wireVarint = 0 // int32, int64, uint32, uint64, sint32, sint64, bool, enum
+		wireData   = 2 // string, bytes, embedded messages, packed repeated fields
 	)
 
 	r := bytes.NewReader(buf)
@@ -30,9 +31,9 @@ func Validate(buf []byte) error {
 			return err
 		}
 
-		// validate the field type
+		// validate the field number and type
 		typ := key & 7
-		if typ != wireVarint && typ != wireData {
+		if key>>3 == 0 || (typ != wireVarint && typ != wireData) {
 			return ErrMalformedProtobuf
 		}
 
@@ -43,7 +44,7 @@ func Validate(buf []byte) error {
 		}
 		if typ == wireData {
 			// field is length-delimited data, skip the data
-			if val > maxFieldSize || int64(val) < 0 {
+			if val > maxFieldSize || val > uint64(r.Len()) {
 				return ErrMalformedProtobuf
 			}
 			_, err = r.Seek(int64(val), ioSeekCurrent)
@@ -0,0 +1,57 @@
+package wire
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	messages "github.com/skycoin/hardware-wallet-protob/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	data, err := proto.Marshal(&messages.SkycoinAddress{
+		AddressN:       proto.Uint32(2),
+		ConfirmAddress: proto.Bool(true),
+	})
+	require.NoError(t, err)
+	require.NoError(t, Validate(data))
+
+	features, err := proto.Marshal(&messages.Features{Vendor: proto.String("Skycoin Foundation"), Label: proto.String("treasury")})
+	require.NoError(t, err)
+	require.NoError(t, Validate(features))
+
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{name: "field number 0", data: []byte{0x00, 0x01}},
+		{name: "fixed 64 bit field", data: []byte{0x09, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{name: "truncated data field", data: []byte{0x0a, 0x05, 'a', 'b'}},
+		{name: "data field over the limit", data: []byte{0x0a, 0x80, 0x80, 0x80, 0x04}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, ErrMalformedProtobuf, Validate(tc.data))
+		})
+	}
+
+	require.Error(t, Validate([]byte{0x08}))
+	require.Error(t, Validate([]byte{0x08, 0x80}))
+}
+
+func FuzzValidate(f *testing.F) {
+	data, err := proto.Marshal(&messages.Features{Vendor: proto.String("Skycoin Foundation"), MajorVersion: proto.Uint32(1)})
+	require.NoError(f, err)
+	f.Add(data)
+	f.Add([]byte{0x0a, 0x05, 'a', 'b'})
+	f.Add([]byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if Validate(data) != nil {
+			return
+		}
+		// any valid buffer can be walked by the protobuf decoder
+		var features messages.Features
+		_ = proto.Unmarshal(data, &features)
+	})
+}
@@ -3,6 +3,7 @@ package wire
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 )
 
@@ -10,102 +11,171 @@ const (
 	repMarker = '?'
 	repMagic  = '#'
 	packetLen = 64
+	// headerLen is the length of the header of the first packet of a message:
+	// marker, two magic bytes, message kind and payload size
+	headerLen = 9
 )
 
+const (
+	// DefaultMaxMessageSize is the largest payload accepted by DefaultCodec, in bytes
+	DefaultMaxMessageSize = maxFieldSize
+	// DefaultMaxResyncPackets is the number of packets DefaultCodec skips
+	// looking for the start of a message before giving up
+	DefaultMaxResyncPackets = 1024
+)
+
+var (
+	ErrMalformedMessage = errors.New("malformed wire format")
+	// ErrNoMessageHeader is returned if no message starts within the resync limit
+	ErrNoMessageHeader = errors.New("no message header found")
+)
+
+// MessageTooLargeError is returned if a message payload is larger than the codec limit
+type MessageTooLargeError struct {
+	Size    uint64
+	MaxSize uint32
+}
+
+func (e MessageTooLargeError) Error() string {
+	return fmt.Sprintf("message of %d bytes exceeds the limit of %d bytes", e.Size, e.MaxSize)
+}
+
 type Message struct {
 	Kind uint16
 	Data []byte
 }
 
-func (m *Message) WriteTo(w io.Writer) (int64, error) {
-	var (
-		rep  [packetLen]byte
-		kind = m.Kind
-		size = uint32(len(m.Data))
-	)
-	// pack header
-	rep[0] = repMarker
-	rep[1] = repMagic
-	rep[2] = repMagic
-	binary.BigEndian.PutUint16(rep[3:], kind)
-	binary.BigEndian.PutUint32(rep[5:], size)
+// Codec encodes and decodes messages framed in 64 byte packets.
+// The first packet holds the header, every packet starts with a marker.
+type Codec struct {
+	// MaxMessageSize is the largest payload accepted, in bytes. DefaultMaxMessageSize if zero.
+	MaxMessageSize uint32
+	// MaxResyncPackets is the number of packets that are skipped looking for
+	// the start of a message. DefaultMaxResyncPackets if zero.
+	MaxResyncPackets int
+}
 
-	var (
-		written = 0 // number of written bytes
-		offset  = 9 // just after the header
-	)
-	for written < len(m.Data) {
-		n := copy(rep[offset:], m.Data[written:])
-		written += n
-		offset += n
-		if offset >= len(rep) {
-			_, err := w.Write(rep[:])
-			if err != nil {
-				return int64(written), err
-			}
-			offset = 1 // just after the marker
-		}
-	}
-	if offset != 1 {
-		for offset < len(rep) {
-			rep[offset] = 0x00
-			offset++
-		}
-		_, err := w.Write(rep[:])
-		if err != nil {
-			return int64(written), err
-		}
+// DefaultCodec is the Codec used by Message.WriteTo and ReadFrom
+var DefaultCodec = Codec{
+	MaxMessageSize:   DefaultMaxMessageSize,
+	MaxResyncPackets: DefaultMaxResyncPackets,
+}
+
+func (c Codec) maxMessageSize() uint32 {
+	if c.MaxMessageSize == 0 {
+		return DefaultMaxMessageSize
 	}
+	return c.MaxMessageSize
+}
 
-	return int64(written), nil
+func (c Codec) maxResyncPackets() int {
+	if c.MaxResyncPackets == 0 {
+		return DefaultMaxResyncPackets
+	}
+	return c.MaxResyncPackets
 }
 
-var (
-	ErrMalformedMessage = errors.New("malformed wire format")
-)
+// Encode splits the message in the packets sent to the device
+func (c Codec) Encode(m Message) ([][packetLen]byte, error) {
+	if uint64(len(m.Data)) > uint64(c.maxMessageSize()) {
+		return nil, MessageTooLargeError{Size: uint64(len(m.Data)), MaxSize: c.maxMessageSize()}
+	}
 
-func ReadFrom(r io.Reader) (*Message, error) {
-	var (
-		rep  [packetLen]byte
-		read = 0 // number of read bytes
-	)
-	n, err := r.Read(rep[:])
+	var header [headerLen]byte
+	header[0] = repMarker
+	header[1] = repMagic
+	header[2] = repMagic
+	binary.BigEndian.PutUint16(header[3:], m.Kind)
+	binary.BigEndian.PutUint32(header[5:], uint32(len(m.Data)))
+
+	var rep [packetLen]byte
+	offset := copy(rep[:], header[:])
+	packets := make([][packetLen]byte, 0, 1+len(m.Data)/(packetLen-1))
+	for written := 0; written < len(m.Data) || len(packets) == 0; {
+		n := copy(rep[offset:], m.Data[written:])
+		written += n
+		packets = append(packets, rep)
+		rep = [packetLen]byte{repMarker}
+		offset = 1
+	}
+	return packets, nil
+}
+
+// WriteMessage writes the packets of the message to w and returns the number of bytes written
+func (c Codec) WriteMessage(w io.Writer, m Message) (int64, error) {
+	packets, err := c.Encode(m)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// skip all the previous messages in the bus
-	for rep[0] != repMarker || rep[1] != repMagic || rep[2] != repMagic {
-		n, err = r.Read(rep[:])
+	var written int64
+	for _, p := range packets {
+		n, err := w.Write(p[:])
+		written += int64(n)
 		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ReadMessage reads a message from r, skipping the packets left in the bus by
+// previous messages until a header is found.
+// Returns ErrNoMessageHeader if no message starts within MaxResyncPackets packets,
+// a MessageTooLargeError if the header announces more than MaxMessageSize bytes
+// and ErrMalformedMessage if a continuation packet lacks its marker.
+func (c Codec) ReadMessage(r io.Reader) (*Message, error) {
+	var rep [packetLen]byte
+	for skipped := 0; ; skipped++ {
+		if skipped > c.maxResyncPackets() {
+			return nil, ErrNoMessageHeader
+		}
+		if _, err := io.ReadFull(r, rep[:]); err != nil {
 			return nil, err
 		}
+		if rep[0] == repMarker && rep[1] == repMagic && rep[2] == repMagic {
+			break
+		}
 	}
-	read += n
 
 	// parse header
 	var (
 		kind = binary.BigEndian.Uint16(rep[3:])
 		size = binary.BigEndian.Uint32(rep[5:])
-		data = make([]byte, 0, size)
 	)
-	data = append(data, rep[9:]...) // read data after header
+	if size > c.maxMessageSize() {
+		return nil, MessageTooLargeError{Size: uint64(size), MaxSize: c.maxMessageSize()}
+	}
 
+	// the buffer grows with the packets actually read instead of trusting the header
+	data := append([]byte{}, rep[headerLen:]...)
 	for uint32(len(data)) < size {
-		n, err := r.Read(rep[:])
-		if err != nil {
+		if _, err := io.ReadFull(r, rep[:]); err != nil {
 			return nil, err
 		}
 		if rep[0] != repMarker {
 			return nil, ErrMalformedMessage
 		}
-		read += n
 		data = append(data, rep[1:]...) // read data after marker
 	}
-	data = data[:size]
 
 	return &Message{
 		Kind: kind,
-		Data: data,
+		Data: data[:size],
 	}, nil
 }
+
+// Packets splits the message in the packets sent to the device using DefaultCodec
+func (m *Message) Packets() ([][packetLen]byte, error) {
+	return DefaultCodec.Encode(*m)
+}
+
+// WriteTo writes the message to w using DefaultCodec
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	return DefaultCodec.WriteMessage(w, *m)
+}
+
+// ReadFrom reads a message from r using DefaultCodec
+func ReadFrom(r io.Reader) (*Message, error) {
+	return DefaultCodec.ReadMessage(r)
+}
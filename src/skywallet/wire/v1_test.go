@@ -0,0 +1,189 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	packets, err := DefaultCodec.Encode(Message{Kind: 0x1234})
+	require.NoError(t, err)
+	require.Len(t, packets, 1)
+	require.Equal(t, []byte{'?', '#', '#', 0x12, 0x34, 0, 0, 0, 0}, packets[0][:headerLen])
+
+	// the first byte of the payload is kept
+	packets, err = DefaultCodec.Encode(Message{Kind: 1, Data: []byte{0x08, 0x01}})
+	require.NoError(t, err)
+	require.Len(t, packets, 1)
+	require.Equal(t, []byte{0x08, 0x01}, packets[0][headerLen:headerLen+2])
+
+	tt := []struct {
+		size    int
+		packets int
+	}{
+		{size: packetLen - headerLen, packets: 1},
+		{size: packetLen - headerLen + 1, packets: 2},
+		{size: packetLen - headerLen + packetLen - 1, packets: 2},
+		{size: packetLen - headerLen + packetLen, packets: 3},
+	}
+	for _, tc := range tt {
+		packets, err := DefaultCodec.Encode(Message{Data: make([]byte, tc.size)})
+		require.NoError(t, err)
+		require.Len(t, packets, tc.packets, "size %d", tc.size)
+		for _, p := range packets[1:] {
+			require.Equal(t, byte(repMarker), p[0])
+		}
+	}
+
+	_, err = Codec{MaxMessageSize: 10}.Encode(Message{Data: make([]byte, 11)})
+	require.Equal(t, MessageTooLargeError{Size: 11, MaxSize: 10}, err)
+}
+
+func TestWriteTo(t *testing.T) {
+	var b bytes.Buffer
+	m := Message{Kind: 17, Data: bytes.Repeat([]byte{0xaa}, 100)}
+	n, err := m.WriteTo(&b)
+	require.NoError(t, err)
+	require.Equal(t, int64(2*packetLen), n)
+	require.Equal(t, 2*packetLen, b.Len())
+}
+
+func TestReadFrom(t *testing.T) {
+	m := Message{Kind: 17, Data: bytes.Repeat([]byte{0xaa}, 100)}
+	packets, err := m.Packets()
+	require.NoError(t, err)
+
+	stale := [packetLen]byte{repMarker, 0x01}
+	var b bytes.Buffer
+	b.Write(stale[:])
+	b.Write(stale[:])
+	for _, p := range packets {
+		b.Write(p[:])
+	}
+
+	read, err := ReadFrom(&b)
+	require.NoError(t, err)
+	require.Equal(t, m, *read)
+}
+
+func TestReadFromErrors(t *testing.T) {
+	header := func(size uint32) [packetLen]byte {
+		p := [packetLen]byte{repMarker, repMagic, repMagic, 0, 1}
+		p[5], p[6], p[7], p[8] = byte(size>>24), byte(size>>16), byte(size>>8), byte(size)
+		return p
+	}
+	packets := func(ps ...[packetLen]byte) *bytes.Buffer {
+		var b bytes.Buffer
+		for _, p := range ps {
+			b.Write(p[:])
+		}
+		return &b
+	}
+	stale := [packetLen]byte{repMarker}
+
+	tt := []struct {
+		name  string
+		codec Codec
+		input *bytes.Buffer
+		err   error
+	}{
+		{
+			name:  "no header within the resync limit",
+			codec: Codec{MaxResyncPackets: 2},
+			input: packets(stale, stale, stale, header(0)),
+			err:   ErrNoMessageHeader,
+		},
+		{
+			name:  "header within the resync limit",
+			codec: Codec{MaxResyncPackets: 2},
+			input: packets(stale, stale, header(0)),
+		},
+		{
+			name:  "size over the limit",
+			codec: Codec{MaxMessageSize: 100},
+			input: packets(header(101)),
+			err:   MessageTooLargeError{Size: 101, MaxSize: 100},
+		},
+		{
+			name:  "size over the default limit",
+			input: packets(header(0xffffffff)),
+			err:   MessageTooLargeError{Size: 0xffffffff, MaxSize: DefaultMaxMessageSize},
+		},
+		{
+			name:  "missing continuation packet",
+			input: packets(header(100)),
+			err:   io.EOF,
+		},
+		{
+			name:  "continuation packet without marker",
+			input: packets(header(100), [packetLen]byte{}),
+			err:   ErrMalformedMessage,
+		},
+		{
+			name:  "short packet",
+			input: bytes.NewBuffer([]byte{repMarker, repMagic, repMagic}),
+			err:   io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.codec.ReadMessage(tc.input)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func FuzzReadFrom(f *testing.F) {
+	m := Message{Kind: 17, Data: bytes.Repeat([]byte{0xaa}, 100)}
+	var b bytes.Buffer
+	_, err := m.WriteTo(&b)
+	require.NoError(f, err)
+	f.Add(b.Bytes())
+	f.Add([]byte{repMarker, repMagic, repMagic, 0, 1, 0xff, 0xff, 0xff, 0xff})
+	f.Add(make([]byte, 3*packetLen))
+
+	codec := Codec{MaxMessageSize: 4096, MaxResyncPackets: 16}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := codec.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		require.LessOrEqual(t, len(msg.Data), 4096)
+
+		// a message read back is encoded the same way again
+		var b bytes.Buffer
+		_, err = codec.WriteMessage(&b, *msg)
+		require.NoError(t, err)
+		again, err := codec.ReadMessage(&b)
+		require.NoError(t, err)
+		require.Equal(t, msg, again)
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(uint16(0), []byte{})
+	f.Add(uint16(17), []byte{0x08, 0x01})
+	f.Add(uint16(0xffff), bytes.Repeat([]byte{repMarker}, 3*packetLen))
+
+	f.Fuzz(func(t *testing.T, kind uint16, data []byte) {
+		m := Message{Kind: kind, Data: data}
+		var b bytes.Buffer
+		n, err := m.WriteTo(&b)
+		require.NoError(t, err)
+		require.Equal(t, int64(b.Len()), n)
+		require.Zero(t, b.Len()%packetLen)
+
+		read, err := ReadFrom(&b)
+		require.NoError(t, err)
+		require.Equal(t, kind, read.Kind)
+		require.Equal(t, len(data), len(read.Data))
+		if len(data) > 0 {
+			require.Equal(t, data, read.Data)
+		}
+		require.Zero(t, b.Len())
+	})
+}